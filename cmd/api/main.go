@@ -2,9 +2,6 @@
 package main
 
 import (
-	"database/sql"
-	"errors"
-	"fmt"
 	"log/slog"
 
 	"github.com/go-chi/chi/v5"
@@ -16,11 +13,6 @@ import (
 	"github.com/krasilnikovm/logman/internal/handler"
 	"github.com/krasilnikovm/logman/internal/service"
 	storage "github.com/krasilnikovm/logman/internal/storage/sqlite"
-
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 var validate = validator.New()
@@ -39,9 +31,16 @@ func main() {
 		logger.Error("can not read envs", slog.String("error", err.Error()))
 	}
 
-	registerRoutes(r, configuration, logger)
+	cipher, err := storage.NewEnvelopeCipher(configuration.MasterKey)
+
+	if err != nil {
+		logger.Error("invalid master key", slog.String("error", err.Error()))
+		return
+	}
 
-	if err := runMigrations(configuration); err != nil {
+	registerRoutes(r, configuration, cipher, logger)
+
+	if err := storage.RunMigrations(configuration.DataStoragePath); err != nil {
 		logger.Error("migrations is not executed", slog.String("error", err.Error()))
 	}
 
@@ -54,20 +53,100 @@ func main() {
 }
 
 // registerRoutes method initialized routes
-func registerRoutes(r *chi.Mux, cfg application.ApiServerConfiguration, logger *slog.Logger) {
+func registerRoutes(r *chi.Mux, cfg application.ApiServerConfiguration, cipher *storage.EnvelopeCipher, logger *slog.Logger) {
+	connStr := storage.ConnectionString(cfg.DataStoragePath)
+
+	connector := service.NewSSHConnector(
+		storage.NewServerStorage(connStr),
+		storage.NewCredentialStorage(connStr, cipher),
+		storage.NewKnownHostStorage(connStr),
+		cfg.AgentSockets,
+		logger,
+	)
+
+	pool := service.NewConnectionPool(
+		connector,
+		service.PoolLimits{
+			MaxSessions:      cfg.SSHMaxSessions,
+			MaxHostSessions:  cfg.SSHMaxHostSessions,
+			MaxTotalSessions: cfg.SSHMaxTotalSessions,
+			RetryAttempts:    cfg.SSHRetryAttempts,
+			RetryBackoff:     cfg.SSHRetryBackoff,
+			BreakerThreshold: cfg.SSHBreakerThreshold,
+			BreakerCooldown:  cfg.SSHBreakerCooldown,
+		},
+		cfg.SSHIdleTimeout,
+		logger,
+	)
 
 	serverHandlers := handler.NewServerHandlers(
 		service.NewServerService(
-			storage.NewServerStorage(cfg.DataStoragePath),
-			storage.NewCredentialStorage(cfg.DataStoragePath),
+			storage.NewServerStorage(connStr),
+			storage.NewCredentialStorage(connStr, cipher),
+			storage.NewLogLocationStorage(connStr),
+			storage.NewKnownHostStorage(connStr),
+			pool,
+			service.NewServerChecker(connector, pool, storage.NewLogLocationStorage(connStr), cfg.LocalRoots),
+			cfg.LocalRoots,
+			logger,
+			validate,
+		),
+	)
+
+	logHandlers := handler.NewLogHandlers(
+		service.NewLogService(
+			storage.NewServerStorage(connStr),
+			storage.NewCredentialStorage(connStr, cipher),
+			storage.NewLogLocationStorage(connStr),
+			storage.NewCustomFormatStorage(connStr),
+			storage.NewGrokPatternStorage(connStr),
+			pool,
+			cfg.LocalRoots,
 			logger,
+		),
+	)
+
+	locationHandlers := handler.NewLogLocationHandlers(
+		service.NewLogLocationService(
+			storage.NewLogLocationStorage(connStr),
+			storage.NewServerStorage(connStr),
+			storage.NewCustomFormatStorage(connStr),
+			storage.NewGrokPatternStorage(connStr),
+			cfg.LocalRoots,
+			validate,
+		),
+	)
+
+	formatHandlers := handler.NewCustomFormatHandlers(
+		service.NewCustomFormatService(
+			storage.NewCustomFormatStorage(connStr),
+			storage.NewLogLocationStorage(connStr),
 			validate,
 		),
 	)
 
+	knownHostHandlers := handler.NewKnownHostHandlers(
+		service.NewKnownHostService(
+			storage.NewKnownHostStorage(connStr),
+		),
+	)
+
+	grokHandlers := handler.NewGrokPatternHandlers(
+		service.NewGrokPatternService(
+			storage.NewGrokPatternStorage(connStr),
+			storage.NewLogLocationStorage(connStr),
+			validate,
+		),
+	)
+
+	adminHandlers := handler.NewAdminHandlers(pool)
+
 	credentialHandlers := handler.NewCredentialHandlers(
 		service.NewCredentialService(
-			storage.NewCredentialStorage(cfg.DataStoragePath),
+			storage.NewCredentialStorage(connStr, cipher),
+			storage.NewServerStorage(connStr),
+			pool,
+			cfg.AgentSockets,
 			validate,
 		),
 	)
@@ -79,48 +158,41 @@ func registerRoutes(r *chi.Mux, cfg application.ApiServerConfiguration, logger *
 	r.Post("/api/v1/servers", serverHandlers.Create)
 	r.Delete("/api/v1/servers/{id:\\d+}", serverHandlers.Delete)
 	r.Patch("/api/v1/servers/{id:\\d+}", serverHandlers.Update)
+	r.Post("/api/v1/servers/{id:\\d+}/check", serverHandlers.Check)
+
+	r.Get("/api/v1/servers/{id:\\d+}/logs", logHandlers.FetchByServer)
+
+	r.Get("/api/v1/servers/{id:\\d+}/locations", locationHandlers.GetList)
+	r.Post("/api/v1/servers/{id:\\d+}/locations", locationHandlers.Create)
+	r.Get("/api/v1/servers/{id:\\d+}/locations/{locationId:\\d+}", locationHandlers.FetchById)
+	r.Patch("/api/v1/servers/{id:\\d+}/locations/{locationId:\\d+}", locationHandlers.Update)
+	r.Delete("/api/v1/servers/{id:\\d+}/locations/{locationId:\\d+}", locationHandlers.Delete)
+
+	r.Get("/api/v1/servers/{id:\\d+}/host-key", knownHostHandlers.FetchByServer)
+	r.Post("/api/v1/servers/{id:\\d+}/host-key/accept", knownHostHandlers.Accept)
+	r.Delete("/api/v1/servers/{id:\\d+}/host-key", knownHostHandlers.Reset)
 
 	r.Get("/api/v1/credentials/{id:\\d+}", credentialHandlers.FetchById)
 	r.Get("/api/v1/credentials", credentialHandlers.GetList)
 	r.Post("/api/v1/credentials", credentialHandlers.Create)
+	r.Post("/api/v1/credentials/generate", credentialHandlers.Generate)
 	r.Delete("/api/v1/credentials/{id:\\d+}", credentialHandlers.Delete)
 	r.Patch("/api/v1/credentials/{id:\\d+}", credentialHandlers.Update)
-}
-
-// runMigrations method up the migrations
-func runMigrations(cfg application.ApiServerConfiguration) error {
-
-	db, err := sql.Open(storage.DriverName, cfg.DataStoragePath)
-
-	if err != nil {
-		return fmt.Errorf("can not open connection: %w", err)
-	}
-
-	defer db.Close()
-
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
-
-	if err != nil {
-		return fmt.Errorf("can not create driver: %w", err)
-	}
-
-	defer driver.Close()
-
-	mgr, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		storage.DriverName,
-		driver,
-	)
-
-	if err != nil {
-		return fmt.Errorf("can not create migrate instance: %w", err)
-	}
-
-	defer mgr.Close()
-
-	if err = mgr.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("execute migration failed: %w", err)
-	}
 
-	return nil
+	r.Get("/api/v1/formats/{id:\\d+}", formatHandlers.FetchById)
+	r.Get("/api/v1/formats", formatHandlers.GetList)
+	r.Post("/api/v1/formats", formatHandlers.Create)
+	r.Post("/api/v1/formats/test", formatHandlers.Test)
+	r.Post("/api/v1/formats/{id:\\d+}/test", formatHandlers.TestById)
+	r.Patch("/api/v1/formats/{id:\\d+}", formatHandlers.Update)
+	r.Delete("/api/v1/formats/{id:\\d+}", formatHandlers.Delete)
+
+	r.Get("/api/v1/grok-patterns/{id:\\d+}", grokHandlers.FetchById)
+	r.Get("/api/v1/grok-patterns", grokHandlers.GetList)
+	r.Post("/api/v1/grok-patterns", grokHandlers.Create)
+	r.Post("/api/v1/grok-patterns/import", grokHandlers.Import)
+	r.Patch("/api/v1/grok-patterns/{id:\\d+}", grokHandlers.Update)
+	r.Delete("/api/v1/grok-patterns/{id:\\d+}", grokHandlers.Delete)
+
+	r.Get("/api/v1/admin/pool", adminHandlers.PoolStats)
 }
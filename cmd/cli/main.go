@@ -0,0 +1,102 @@
+// Package main in cmd/cli directory contains maintenance commands of logman.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ilyakaznacheev/cleanenv"
+
+	"github.com/krasilnikovm/logman/internal/application"
+	storage "github.com/krasilnikovm/logman/internal/storage/sqlite"
+)
+
+const usage = `Usage: logman <command>
+
+Commands:
+  generate-master-key  prints new random master key
+  rotate-master-key    re-encrypts credentials stored with LOGMAN_MASTER_KEY by LOGMAN_NEW_MASTER_KEY
+`
+
+// A RotationConfiguration contains config of the master key rotation
+type RotationConfiguration struct {
+	application.Configuration
+
+	// NewMasterKey contains master key which replaces the current one, the value reads from "LOGMAN_NEW_MASTER_KEY"
+	NewMasterKey string `env:"LOGMAN_NEW_MASTER_KEY"`
+}
+
+// main is an entrypoint of logman cli
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "generate-master-key":
+		err = generateMasterKey()
+	case "rotate-master-key":
+		err = rotateMasterKey()
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generateMasterKey() error {
+	key, err := storage.GenerateMasterKey()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(key)
+
+	return nil
+}
+
+// rotateMasterKey re-encrypts data keys of all credentials, after the rotation
+// LOGMAN_MASTER_KEY must be replaced by the value of LOGMAN_NEW_MASTER_KEY
+func rotateMasterKey() error {
+	cfg := RotationConfiguration{}
+
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return fmt.Errorf("can not read envs: %w", err)
+	}
+
+	current, err := storage.NewEnvelopeCipher(cfg.MasterKey)
+
+	if err != nil {
+		return fmt.Errorf("invalid LOGMAN_MASTER_KEY: %w", err)
+	}
+
+	next, err := storage.NewEnvelopeCipher(cfg.NewMasterKey)
+
+	if err != nil {
+		return fmt.Errorf("invalid LOGMAN_NEW_MASTER_KEY: %w", err)
+	}
+
+	// rotation reads columns added by migrations, so the database must be on the latest schema like api server makes it
+	if err := storage.RunMigrations(cfg.DataStoragePath); err != nil {
+		return fmt.Errorf("migrations is not executed: %w", err)
+	}
+
+	count, err := storage.NewCredentialStorage(storage.ConnectionString(cfg.DataStoragePath), current).RotateMasterKey(context.Background(), next)
+
+	if err != nil {
+		return fmt.Errorf("master key rotation failed: %w", err)
+	}
+
+	fmt.Printf("%d credentials re-encrypted, set LOGMAN_MASTER_KEY to the new key\n", count)
+
+	return nil
+}
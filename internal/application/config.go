@@ -1,5 +1,7 @@
 package application
 
+import "time"
+
 // A Configuration contains application config which must contains every application(cli, api)
 type Configuration struct {
 	// AppEnv contains current environment the value gets from "LOGMAN_ENV" environment variable
@@ -9,6 +11,10 @@ type Configuration struct {
 	// DataStoragePath contains path to sqlite database, by default the value is var/data/logman.db
 	// to override the path need to set env variable "LOGMAN_DB_PATH"
 	DataStoragePath string `env:"LOGMAN_DB_PATH" env-default:"var/data/logman.db"`
+
+	// MasterKey contains base64 encoded 32 bytes key which encrypts credential secrets in the database,
+	// the value gets from "LOGMAN_MASTER_KEY" environment variable, without the key secrets can not be stored
+	MasterKey string `env:"LOGMAN_MASTER_KEY"`
 }
 
 // A ApiServerConfiguration contains application config related to api server
@@ -18,4 +24,44 @@ type ApiServerConfiguration struct {
 	// Port shows on which ports works api server, the value reads from "LOGMAN_PORT" environment variable
 	// if the environment variable is not set then will be use "8016" port
 	Port string `env:"LOGMAN_PORT" env-default:"8016"`
+
+	// SSHIdleTimeout shows how long pooled ssh connection is kept open without sessions,
+	// the value reads from "LOGMAN_SSH_IDLE_TIMEOUT" environment variable, by default 5 minutes
+	SSHIdleTimeout time.Duration `env:"LOGMAN_SSH_IDLE_TIMEOUT" env-default:"5m"`
+
+	// SSHMaxSessions limits number of concurrent sessions over one ssh connection,
+	// the value reads from "LOGMAN_SSH_MAX_SESSIONS" environment variable, by default 8, 0 means no limit
+	SSHMaxSessions int `env:"LOGMAN_SSH_MAX_SESSIONS" env-default:"8"`
+
+	// SSHMaxHostSessions limits number of concurrent sessions to one host shared by all its servers,
+	// the value reads from "LOGMAN_SSH_MAX_HOST_SESSIONS" environment variable, by default 8, 0 means no limit
+	SSHMaxHostSessions int `env:"LOGMAN_SSH_MAX_HOST_SESSIONS" env-default:"8"`
+
+	// SSHMaxTotalSessions limits number of concurrent sessions to all servers,
+	// the value reads from "LOGMAN_SSH_MAX_TOTAL_SESSIONS" environment variable, by default 64, 0 means no limit
+	SSHMaxTotalSessions int `env:"LOGMAN_SSH_MAX_TOTAL_SESSIONS" env-default:"64"`
+
+	// SSHRetryAttempts shows how many times connection failed by transient network error is retried,
+	// the value reads from "LOGMAN_SSH_RETRY_ATTEMPTS" environment variable, by default 2
+	SSHRetryAttempts int `env:"LOGMAN_SSH_RETRY_ATTEMPTS" env-default:"2"`
+
+	// SSHRetryBackoff is a delay before the first retry which is doubled for every next one,
+	// the value reads from "LOGMAN_SSH_RETRY_BACKOFF" environment variable, by default 500 milliseconds
+	SSHRetryBackoff time.Duration `env:"LOGMAN_SSH_RETRY_BACKOFF" env-default:"500ms"`
+
+	// SSHBreakerThreshold is a number of consecutive connection failures which stops connecting to the server,
+	// the value reads from "LOGMAN_SSH_BREAKER_THRESHOLD" environment variable, by default 5, 0 disables the breaker
+	SSHBreakerThreshold int `env:"LOGMAN_SSH_BREAKER_THRESHOLD" env-default:"5"`
+
+	// SSHBreakerCooldown shows how long connections to the server are not attempted after the breaker is opened,
+	// the value reads from "LOGMAN_SSH_BREAKER_COOLDOWN" environment variable, by default 1 minute
+	SSHBreakerCooldown time.Duration `env:"LOGMAN_SSH_BREAKER_COOLDOWN" env-default:"1m"`
+
+	// LocalRoots contains directories which log locations of local servers may read, the value reads from
+	// comma separated "LOGMAN_LOCAL_ROOTS" environment variable, by default /var/log
+	LocalRoots []string `env:"LOGMAN_LOCAL_ROOTS" env-separator:"," env-default:"/var/log"`
+
+	// AgentSockets contains ssh-agent sockets which agent credentials may use, the value reads from comma separated
+	// "LOGMAN_AGENT_SOCKETS" environment variable, by default only SSH_AUTH_SOCK of logman is allowed
+	AgentSockets []string `env:"LOGMAN_AGENT_SOCKETS" env-separator:","`
 }
@@ -1,11 +1,31 @@
 package entity
 
+const (
+	// CredentialKindKey is a private key file located on the logman host, the key may be protected by Passphrase
+	CredentialKindKey = "key"
+	// CredentialKindPassword is a password authentication
+	CredentialKindPassword = "password"
+	// CredentialKindAgent is a running ssh-agent reachable by AgentSocket
+	CredentialKindAgent = "agent"
+	// CredentialKindInlineKey is a private key stored in logman, the key may be protected by Passphrase
+	CredentialKindInlineKey = "inline_key"
+)
+
 type KeyPath string
+type CredentialKind string
 
+// A Credential authenticates logman on servers, Certificate of key and inline key credentials
+// is an optional OpenSSH user certificate of the key signed by CA
 type Credential struct {
-	Id        int
-	Name      string  `validate:"required"`
-	Path      KeyPath `validate:"required"`
-	CreatedAt string  `validate:"required"`
-	UpdatedAt string  `validate:"required"`
+	Id          int
+	Name        string         `validate:"required"`
+	Kind        CredentialKind `validate:"required,oneof=key password agent inline_key"`
+	Path        KeyPath        `validate:"required_if=Kind key,excluded_unless=Kind key"`
+	Passphrase  string         `validate:"excluded_if=Kind password,excluded_if=Kind agent"`
+	Password    string         `validate:"required_if=Kind password,excluded_unless=Kind password"`
+	AgentSocket string         `validate:"required_if=Kind agent,excluded_unless=Kind agent"`
+	PrivateKey  string         `validate:"required_if=Kind inline_key,excluded_unless=Kind inline_key"`
+	Certificate string         `validate:"excluded_if=Kind password,excluded_if=Kind agent"`
+	CreatedAt   string         `validate:"required"`
+	UpdatedAt   string         `validate:"required"`
 }
@@ -0,0 +1,17 @@
+package entity
+
+// A CustomFormat is a user defined log format, Pattern is a regular expression with named groups which become
+// fields of the entry, groups named by TimestampField, LevelField and MessageField become timestamp, level
+// and message of the entry, Levels maps values of the level group to the levels
+type CustomFormat struct {
+	Id              int
+	Name            string            `validate:"required,max=128"`
+	Pattern         string            `validate:"required,max=4096"`
+	TimestampField  string            `validate:"max=128"`
+	TimestampLayout string            `validate:"max=128"`
+	LevelField      string            `validate:"max=128"`
+	Levels          map[string]string `validate:"max=64"`
+	MessageField    string            `validate:"max=128"`
+	CreatedAt       string            `validate:"required"`
+	UpdatedAt       string            `validate:"required"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+// A GrokPattern is a user defined grok pattern referenced by Name from grok expressions and other patterns,
+// it replaces the standard pattern of the same Name
+type GrokPattern struct {
+	Id        int
+	Name      string `validate:"required,max=128"`
+	Pattern   string `validate:"required,max=4096"`
+	CreatedAt string `validate:"required"`
+	UpdatedAt string `validate:"required"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+// A KnownHost is a host key pinned for the Server on the first connection
+type KnownHost struct {
+	Id          int
+	ServerId    int    `validate:"required"`
+	Host        string `validate:"required"`
+	KeyType     string `validate:"required"`
+	Fingerprint string `validate:"required"`
+	PublicKey   string `validate:"required"`
+
+	// Pending* fields contain the last key offered by the host which differs from the pinned one,
+	// the pending key replaces pinned key only after explicit acceptance
+	PendingKeyType     string
+	PendingFingerprint string
+	PendingPublicKey   string
+
+	CreatedAt string `validate:"required"`
+	UpdatedAt string `validate:"required"`
+}
+
+// HasPendingKey shows whether host offered a key which differs from the pinned one
+func (k KnownHost) HasPendingKey() bool {
+	return k.PendingPublicKey != ""
+}
@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// LogLocationFormatJson is a json format of log location
+	LogLocationFormatJson = "json"
+	// LogLocationFormatLogfmt is a logfmt format of log location, it is written by slog.TextHandler and logrus
+	LogLocationFormatLogfmt = "logfmt"
+	// LogLocationFormatNginx is a nginx access log written by log_format directive from Pattern of log location
+	LogLocationFormatNginx = "nginx"
+	// LogLocationFormatNginxCombined is a nginx access log of predefined combined log_format
+	LogLocationFormatNginxCombined = "nginx_combined"
+	// LogLocationFormatNginxMain is a nginx access log of main log_format from default nginx.conf
+	LogLocationFormatNginxMain = "nginx_main"
+	// LogLocationFormatApacheCommon is an apache access log of common LogFormat
+	LogLocationFormatApacheCommon = "apache_common"
+	// LogLocationFormatApacheCombined is an apache access log of combined LogFormat
+	LogLocationFormatApacheCombined = "apache_combined"
+	// LogLocationFormatSyslogRfc3164 is a BSD syslog format of /var/log/syslog and /var/log/messages
+	LogLocationFormatSyslogRfc3164 = "syslog_rfc3164"
+	// LogLocationFormatSyslogRfc5424 is a syslog format of RFC 5424 with structured data
+	LogLocationFormatSyslogRfc5424 = "syslog_rfc5424"
+	// LogLocationFormatGrok is a format of lines matched by grok expression from Pattern of log location
+	LogLocationFormatGrok = "grok"
+	// LogLocationFormatCustomPrefix is a prefix of format referencing CustomFormat by id like "custom:1"
+	LogLocationFormatCustomPrefix = "custom:"
+
+	// LogLocationKindFile is a set of log files matched by glob Path
+	LogLocationKindFile = "file"
+	// LogLocationKindJournald is a systemd journal read by journalctl, optionally filtered by unit and priority
+	LogLocationKindJournald = "journald"
+	// LogLocationKindDocker is a set of running docker containers, optionally filtered by name and label
+	LogLocationKindDocker = "docker"
+)
+
+type LogFormat string
+
+// A LogLocation is a source of logs on the Server, Path of file location is a glob pattern like "/var/log/app/*.log",
+// Unit and Priority filter journald location, ContainerName and ContainerLabel filter docker location
+// the same way as filters of "docker ps", Pattern is a log_format string of nginx format
+// or an expression of grok format
+type LogLocation struct {
+	Id             int
+	ServerId       int       `validate:"required"`
+	Name           string    `validate:"required,max=128"`
+	Kind           string    `validate:"required,oneof=file journald docker"`
+	Path           string    `validate:"required_if=Kind file,excluded_unless=Kind file,omitempty,startswith=/"`
+	Format         LogFormat `validate:"required,oneof=json logfmt nginx nginx_combined nginx_main apache_common apache_combined syslog_rfc3164 syslog_rfc5424 grok|startswith=custom:"`
+	Pattern        string    `validate:"required_if=Format nginx,required_if=Format grok,excluded_unless=Format nginx|excluded_unless=Format grok,omitempty,max=4096"`
+	Unit           string    `validate:"excluded_unless=Kind journald,omitempty,max=256"`
+	Priority       string    `validate:"excluded_unless=Kind journald,omitempty,oneof=emerg alert crit err warning notice info debug 0 1 2 3 4 5 6 7"`
+	ContainerName  string    `validate:"excluded_unless=Kind docker,omitempty,max=256"`
+	ContainerLabel string    `validate:"excluded_unless=Kind docker,omitempty,max=256"`
+	CreatedAt      string    `validate:"required"`
+	UpdatedAt      string    `validate:"required"`
+}
+
+// CustomFormatId returns id of the CustomFormat referenced by the Format, false means the Format is built-in
+// or the id is not written in canonical form like custom:01, so every CustomFormat has the only reference
+func (l LogLocation) CustomFormatId() (int, bool) {
+	value, ok := strings.CutPrefix(string(l.Format), LogLocationFormatCustomPrefix)
+
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(value)
+
+	return id, err == nil && id > 0 && strconv.Itoa(id) == value
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "testing"
+
+func TestLogLocationCustomFormatId(t *testing.T) {
+	tests := []struct {
+		format LogFormat
+		id     int
+		ok     bool
+	}{
+		{format: "custom:1", id: 1, ok: true},
+		{format: "custom:42", id: 42, ok: true},
+		{format: "custom:01"},
+		{format: "custom:+1"},
+		{format: "custom:0"},
+		{format: "custom:-1"},
+		{format: "custom:"},
+		{format: "json"},
+	}
+
+	for _, tt := range tests {
+		id, ok := LogLocation{Format: tt.format}.CustomFormatId()
+
+		if ok != tt.ok || ok && id != tt.id {
+			t.Errorf("CustomFormatId() of %q = %d, %v, want %d, %v", tt.format, id, ok, tt.id, tt.ok)
+		}
+	}
+}
@@ -1,20 +1,46 @@
 package entity
 
 const (
-	// LogLocationFormatJson is a json format of log location
-	LogLocationFormatJson = "json"
-)
+	// DefaultServerPort is a port of ssh daemon used when the port is not specified
+	DefaultServerPort = 22
+	// DefaultConnectTimeout is a timeout in seconds of establishing ssh connection
+	DefaultConnectTimeout = 10
+	// DefaultKeepAliveCountMax is a number of unanswered keepalive requests after which connection is closed
+	DefaultKeepAliveCountMax = 3
+
+	// ServerTransportAuto reads logs over sftp and falls back to exec when sftp subsystem is not available
+	ServerTransportAuto = "auto"
+	// ServerTransportSftp reads logs over sftp subsystem, suitable for hosts with "ForceCommand internal-sftp"
+	ServerTransportSftp = "sftp"
+	// ServerTransportExec reads logs by running find and cat commands
+	ServerTransportExec = "exec"
 
-type LogFolderPath string
-type LogFormat string
+	// ServerKindSsh is a remote host reached over ssh
+	ServerKindSsh = "ssh"
+	// ServerKindLocal is the host logman runs on, logs are read from the local disk without credential
+	ServerKindLocal = "local"
+)
 
+// A Server is a host with logs, JumpServerId references a Server used as a jump host to reach it,
+// zero JumpServerId means direct connection. Empty Username means the user who runs logman,
+// ConnectTimeout and KeepAliveInterval are in seconds, zero KeepAliveInterval disables keepalive requests.
+// UseSudo makes logman read logs through sudo, so files readable only by root are available,
+// sudo is supported only by exec Transport. Connection options are used only by ssh Kind.
+// Logs of the Server are read from its LogLocation list
 type Server struct {
-	Id            int
-	Name          string        `validate:"required"`
-	Host          string        `validate:"required,hostname|ip"`
-	LogFolderPath LogFolderPath `validate:"required"`
-	LogFormat     LogFormat     `validate:"required,eq=json"`
-	CredentialId  int           `validate:"required"`
-	CreatedAt     string        `validate:"required"`
-	UpdatedAt     string        `validate:"required"`
+	Id                int
+	Name              string `validate:"required"`
+	Kind              string `validate:"required,oneof=ssh local"`
+	Host              string `validate:"required_if=Kind ssh,omitempty,hostname|ip"`
+	Port              int    `validate:"required,min=1,max=65535"`
+	Username          string `validate:"excluded_if=Kind local,omitempty,max=64,excludesall= @:"`
+	ConnectTimeout    int    `validate:"required,min=1,max=300"`
+	KeepAliveInterval int    `validate:"min=0,max=3600"`
+	KeepAliveCountMax int    `validate:"required,min=1,max=100"`
+	CredentialId      int    `validate:"required_if=Kind ssh,excluded_if=Kind local"`
+	JumpServerId      int    `validate:"excluded_if=Kind local,omitempty,nefield=Id"`
+	CreatedAt         string `validate:"required"`
+	UpdatedAt         string `validate:"required"`
+	UseSudo           bool   `validate:"excluded_if=Transport sftp,excluded_if=Kind local"`
+	Transport         string `validate:"required,oneof=auto sftp exec"`
 }
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/krasilnikovm/logman/internal/service"
+)
+
+type PoolStatsProvider interface {
+	Stats() service.PoolStatsResponse
+}
+
+type AdminHandlers struct {
+	pool PoolStatsProvider
+}
+
+func NewAdminHandlers(pool PoolStatsProvider) *AdminHandlers {
+	return &AdminHandlers{
+		pool: pool,
+	}
+}
+
+// PoolStats is a HandlerFunc which returns state of pooled ssh connections
+func (s *AdminHandlers) PoolStats(w http.ResponseWriter, r *http.Request) {
+	writeOkJson(w, s.pool.Stats())
+}
@@ -13,8 +13,9 @@ import (
 
 type CredentialServiceContract interface {
 	Create(ctx context.Context, data service.CredentialData) (service.CredentialResponse, error)
+	Generate(ctx context.Context, data service.CredentialGenerateData) (*service.CredentialGenerateResponse, error)
 	Update(ctx context.Context, id int, data service.CredentialData) (*service.CredentialResponse, error)
-	DeleteById(ctx context.Context, id int) error
+	DeleteById(ctx context.Context, id, reassignTo int) error
 	GetList(ctx context.Context, page, limit int) ([]service.CredentialResponse, error)
 	GetById(ctx context.Context, id int) (*service.CredentialResponse, error)
 }
@@ -37,10 +38,30 @@ func (s *CredentialHandlers) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := s.credentialService.Create(r.Context(), service.CredentialData{
-		Path: request.Path,
-		Name: request.Name,
-	})
+	response, err := s.credentialService.Create(r.Context(), request)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func (s *CredentialHandlers) Generate(w http.ResponseWriter, r *http.Request) {
+	var request service.CredentialGenerateData
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.credentialService.Generate(r.Context(), request)
 
 	if errors.As(err, &service.ErrValidation{}) {
 		writeValidationJson(w, err.(service.ErrValidation))
@@ -86,7 +107,28 @@ func (s *CredentialHandlers) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.credentialService.DeleteById(r.Context(), id)
+	reassignTo := 0
+
+	if v := r.URL.Query().Get("reassignTo"); v != "" {
+		if reassignTo, err = strconv.Atoi(v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = s.credentialService.DeleteById(r.Context(), id, reassignTo)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	var inUse service.ErrCredentialInUse
+
+	if errors.As(err, &inUse) {
+		writeInUseJson(w, inUse, inUse.Servers)
+		return
+	}
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -134,14 +176,7 @@ func (s *CredentialHandlers) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := s.credentialService.Update(
-		r.Context(),
-		id,
-		service.CredentialData{
-			Path: request.Path,
-			Name: request.Name,
-		},
-	)
+	response, err := s.credentialService.Update(r.Context(), id, request)
 
 	if errors.As(err, &service.ErrValidation{}) {
 		writeValidationJson(w, err.(service.ErrValidation))
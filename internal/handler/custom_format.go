@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krasilnikovm/logman/internal/service"
+)
+
+type CustomFormatServiceContract interface {
+	GetList(ctx context.Context, page, limit int) ([]service.CustomFormatResponse, error)
+	GetById(ctx context.Context, id int) (*service.CustomFormatResponse, error)
+	Create(ctx context.Context, data service.CustomFormatData) (*service.CustomFormatResponse, error)
+	Update(ctx context.Context, id int, data service.CustomFormatData) (*service.CustomFormatResponse, error)
+	DeleteById(ctx context.Context, id int) error
+	Test(ctx context.Context, data service.CustomFormatTestData) ([]service.CustomFormatTestResult, error)
+	TestById(ctx context.Context, id int, lines []string) ([]service.CustomFormatTestResult, error)
+}
+
+type CustomFormatHandlers struct {
+	formatService CustomFormatServiceContract
+}
+
+func NewCustomFormatHandlers(s CustomFormatServiceContract) *CustomFormatHandlers {
+	return &CustomFormatHandlers{
+		formatService: s,
+	}
+}
+
+// GetList is a HandlerFunc which returns page of custom formats
+func (s *CustomFormatHandlers) GetList(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if err != nil {
+		limit = 10
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+
+	if err != nil {
+		page = 1
+	}
+
+	response, err := s.formatService.GetList(r.Context(), page, limit)
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// FetchById is a HandlerFunc which returns the custom format
+func (s *CustomFormatHandlers) FetchById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.formatService.GetById(r.Context(), id)
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// Create is a HandlerFunc which adds new custom format
+func (s *CustomFormatHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	var requestBody service.CustomFormatData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.formatService.Create(r.Context(), requestBody)
+
+	s.writeResponse(w, response, err)
+}
+
+// Update is a HandlerFunc which replaces fields of the custom format
+func (s *CustomFormatHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var requestBody service.CustomFormatData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.formatService.Update(r.Context(), id, requestBody)
+
+	s.writeResponse(w, response, err)
+}
+
+// Delete is a HandlerFunc which deletes the custom format, formats used by log locations are not deleted
+func (s *CustomFormatHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = s.formatService.DeleteById(r.Context(), id)
+
+	if errors.Is(err, service.ErrCustomFormatInUse) {
+		writeErrorJson(w, http.StatusConflict, err)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeWithEmptyBody(w)
+}
+
+// Test is a HandlerFunc which previews parsing of sample lines by the format which is not saved yet
+func (s *CustomFormatHandlers) Test(w http.ResponseWriter, r *http.Request) {
+	var requestBody service.CustomFormatTestData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.formatService.Test(r.Context(), requestBody)
+
+	s.writeTestResponse(w, response, err)
+}
+
+// TestById is a HandlerFunc which previews parsing of sample lines by the saved custom format
+func (s *CustomFormatHandlers) TestById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var requestBody service.CustomFormatTestData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.formatService.TestById(r.Context(), id, requestBody.Lines)
+
+	s.writeTestResponse(w, response, err)
+}
+
+func (s *CustomFormatHandlers) writeResponse(w http.ResponseWriter, response *service.CustomFormatResponse, err error) {
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func (s *CustomFormatHandlers) writeTestResponse(w http.ResponseWriter, response []service.CustomFormatTestResult, err error) {
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
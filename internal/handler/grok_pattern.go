@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krasilnikovm/logman/internal/service"
+)
+
+type GrokPatternServiceContract interface {
+	GetList(ctx context.Context) ([]service.GrokPatternResponse, error)
+	GetById(ctx context.Context, id int) (*service.GrokPatternResponse, error)
+	Create(ctx context.Context, data service.GrokPatternData) (*service.GrokPatternResponse, error)
+	Update(ctx context.Context, id int, data service.GrokPatternData) (*service.GrokPatternResponse, error)
+	DeleteById(ctx context.Context, id int) error
+	Import(ctx context.Context, data service.GrokImportData) ([]service.GrokPatternResponse, error)
+}
+
+type GrokPatternHandlers struct {
+	patternService GrokPatternServiceContract
+}
+
+func NewGrokPatternHandlers(s GrokPatternServiceContract) *GrokPatternHandlers {
+	return &GrokPatternHandlers{
+		patternService: s,
+	}
+}
+
+// GetList is a HandlerFunc which returns user defined grok patterns
+func (s *GrokPatternHandlers) GetList(w http.ResponseWriter, r *http.Request) {
+	response, err := s.patternService.GetList(r.Context())
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// FetchById is a HandlerFunc which returns the grok pattern
+func (s *GrokPatternHandlers) FetchById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.patternService.GetById(r.Context(), id)
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// Create is a HandlerFunc which adds new grok pattern
+func (s *GrokPatternHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	var requestBody service.GrokPatternData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.patternService.Create(r.Context(), requestBody)
+
+	s.writeResponse(w, response, err)
+}
+
+// Update is a HandlerFunc which replaces name and pattern of the grok pattern
+func (s *GrokPatternHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var requestBody service.GrokPatternData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.patternService.Update(r.Context(), id, requestBody)
+
+	s.writeResponse(w, response, err)
+}
+
+// Delete is a HandlerFunc which deletes the grok pattern, patterns which are in use are not deleted
+func (s *GrokPatternHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = s.patternService.DeleteById(r.Context(), id)
+
+	if errors.Is(err, service.ErrGrokPatternInUse) {
+		writeErrorJson(w, http.StatusConflict, err)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeWithEmptyBody(w)
+}
+
+// Import is a HandlerFunc which adds grok patterns of logstash patterns file
+func (s *GrokPatternHandlers) Import(w http.ResponseWriter, r *http.Request) {
+	var requestBody service.GrokImportData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.patternService.Import(r.Context(), requestBody)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func (s *GrokPatternHandlers) writeResponse(w http.ResponseWriter, response *service.GrokPatternResponse, err error) {
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
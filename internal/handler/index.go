@@ -38,7 +38,30 @@ func writeValidationJson(w http.ResponseWriter, err service.ErrValidation) {
 	json.NewEncoder(w).Encode(validationResponse{Errors: err.Errors})
 }
 
+func writeInUseJson(w http.ResponseWriter, err error, servers []service.ServerReference) {
+	type conflictResponse struct {
+		Error   string                    `json:"error"`
+		Servers []service.ServerReference `json:"servers"`
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+
+	json.NewEncoder(w).Encode(conflictResponse{Error: err.Error(), Servers: servers})
+}
+
 func writeWithEmptyBody(w http.ResponseWriter) {
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func writeErrorJson(w http.ResponseWriter, status int, err error) {
+	type errorResponse struct {
+		Error string `json:"error"`
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krasilnikovm/logman/internal/service"
+)
+
+type KnownHostServiceContract interface {
+	GetByServerId(ctx context.Context, serverId int) (*service.KnownHostResponse, error)
+	Accept(ctx context.Context, serverId int) (*service.KnownHostResponse, error)
+	Reset(ctx context.Context, serverId int) error
+}
+
+type KnownHostHandlers struct {
+	knownHostService KnownHostServiceContract
+}
+
+func NewKnownHostHandlers(s KnownHostServiceContract) *KnownHostHandlers {
+	return &KnownHostHandlers{
+		knownHostService: s,
+	}
+}
+
+// FetchByServer is a HandlerFunc which returns pinned and pending host keys of the server
+func (s *KnownHostHandlers) FetchByServer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.knownHostService.GetByServerId(r.Context(), id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// Accept is a HandlerFunc which pins the pending host key of the server
+func (s *KnownHostHandlers) Accept(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.knownHostService.Accept(r.Context(), id)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// Reset is a HandlerFunc which removes the pinned host key of the server
+func (s *KnownHostHandlers) Reset(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := s.knownHostService.Reset(r.Context(), id); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeWithEmptyBody(w)
+}
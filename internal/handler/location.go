@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krasilnikovm/logman/internal/service"
+)
+
+type LogLocationServiceContract interface {
+	GetList(ctx context.Context, serverId int) ([]service.LogLocationResponse, error)
+	GetById(ctx context.Context, serverId, id int) (*service.LogLocationResponse, error)
+	Create(ctx context.Context, serverId int, data service.LogLocationData) (*service.LogLocationResponse, error)
+	Update(ctx context.Context, serverId, id int, data service.LogLocationData) (*service.LogLocationResponse, error)
+	DeleteById(ctx context.Context, serverId, id int) error
+}
+
+type LogLocationHandlers struct {
+	locationService LogLocationServiceContract
+}
+
+func NewLogLocationHandlers(s LogLocationServiceContract) *LogLocationHandlers {
+	return &LogLocationHandlers{
+		locationService: s,
+	}
+}
+
+// GetList is a HandlerFunc which returns log locations of the server
+func (s *LogLocationHandlers) GetList(w http.ResponseWriter, r *http.Request) {
+	serverId, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.locationService.GetList(r.Context(), serverId)
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// FetchById is a HandlerFunc which returns the log location of the server
+func (s *LogLocationHandlers) FetchById(w http.ResponseWriter, r *http.Request) {
+	serverId, id, err := readLocationIds(r)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.locationService.GetById(r.Context(), serverId, id)
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// Create is a HandlerFunc which adds new log location to the server
+func (s *LogLocationHandlers) Create(w http.ResponseWriter, r *http.Request) {
+	serverId, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var requestBody service.LogLocationData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.locationService.Create(r.Context(), serverId, requestBody)
+
+	s.writeResponse(w, response, err)
+}
+
+// Update is a HandlerFunc which replaces fields of the log location
+func (s *LogLocationHandlers) Update(w http.ResponseWriter, r *http.Request) {
+	serverId, id, err := readLocationIds(r)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var requestBody service.LogLocationData
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.locationService.Update(r.Context(), serverId, id, requestBody)
+
+	s.writeResponse(w, response, err)
+}
+
+// Delete is a HandlerFunc which deletes the log location
+func (s *LogLocationHandlers) Delete(w http.ResponseWriter, r *http.Request) {
+	serverId, id, err := readLocationIds(r)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := s.locationService.DeleteById(r.Context(), serverId, id); err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeWithEmptyBody(w)
+}
+
+func (s *LogLocationHandlers) writeResponse(w http.ResponseWriter, response *service.LogLocationResponse, err error) {
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func readLocationIds(r *http.Request) (int, int, error) {
+	serverId, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "locationId"))
+
+	return serverId, id, err
+}
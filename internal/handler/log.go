@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krasilnikovm/logman/internal/service"
+)
+
+type LogServiceContract interface {
+	FetchByServerId(ctx context.Context, id int, query service.LogQuery) (*service.LogsResponse, error)
+}
+
+type LogHandlers struct {
+	logService LogServiceContract
+}
+
+func NewLogHandlers(s LogServiceContract) *LogHandlers {
+	return &LogHandlers{
+		logService: s,
+	}
+}
+
+// FetchByServer is a HandlerFunc which returns parsed log entries of the server,
+// supported query parameters are limit, query, regex, since, until and location
+func (s *LogHandlers) FetchByServer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	query, err := readLogQuery(r)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.logService.FetchByServerId(r.Context(), id, query)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if errors.Is(err, service.ErrHostKeyMismatch) {
+		writeErrorJson(w, http.StatusConflict, err)
+		return
+	}
+
+	if errors.Is(err, service.ErrPrivilegeDenied) || errors.Is(err, service.ErrPathNotAllowed) {
+		writeErrorJson(w, http.StatusForbidden, err)
+		return
+	}
+
+	if errors.Is(err, service.ErrCircuitOpen) {
+		writeErrorJson(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	if errors.Is(err, service.ErrConnectionFailed) {
+		writeErrorJson(w, http.StatusBadGateway, err)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func readLogQuery(r *http.Request) (service.LogQuery, error) {
+	values := r.URL.Query()
+
+	query := service.LogQuery{
+		Search: values.Get("query"),
+		Regex:  values.Get("regex") == "true",
+	}
+
+	if v := values.Get("location"); v != "" {
+		locationId, err := strconv.Atoi(v)
+
+		if err != nil {
+			return query, err
+		}
+
+		query.LocationId = locationId
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+
+		if err != nil {
+			return query, err
+		}
+
+		query.Limit = limit
+	}
+
+	for param, target := range map[string]**time.Time{"since": &query.Since, "until": &query.Until} {
+		if v := values.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+
+			if err != nil {
+				return query, err
+			}
+
+			*target = &t
+		}
+	}
+
+	return query, nil
+}
@@ -19,6 +19,9 @@ type ServerServiceContract interface {
 	DeleteById(ctx context.Context, id int) error
 	GetList(ctx context.Context, limit, page int) ([]service.ServerResponse, error)
 	Update(ctx context.Context, id int, data service.ServerData) (*service.ServerResponse, error)
+	CreateDryRun(ctx context.Context, data service.ServerData) (*service.DryRunResponse, error)
+	UpdateDryRun(ctx context.Context, id int, data service.ServerData) (*service.DryRunResponse, error)
+	Check(ctx context.Context, id int) (*service.CheckResponse, error)
 }
 
 type ServerHandlers struct {
@@ -65,6 +68,11 @@ func (s *ServerHandlers) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isDryRun(r) {
+		s.createDryRun(w, r, requestBody)
+		return
+	}
+
 	response, err := s.serverService.Create(r.Context(), requestBody)
 
 	if errors.As(err, &service.ErrValidation{}) {
@@ -89,7 +97,16 @@ func (s *ServerHandlers) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.serverService.DeleteById(r.Context(), id); err != nil {
+	err = s.serverService.DeleteById(r.Context(), id)
+
+	var inUse service.ErrServerInUse
+
+	if errors.As(err, &inUse) {
+		writeInUseJson(w, inUse, inUse.Servers)
+		return
+	}
+
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -137,6 +154,11 @@ func (s *ServerHandlers) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isDryRun(r) {
+		s.updateDryRun(w, r, id, requestBody)
+		return
+	}
+
 	response, err := s.serverService.Update(r.Context(), id, requestBody)
 
 	if errors.As(err, &service.ErrValidation{}) {
@@ -150,5 +172,79 @@ func (s *ServerHandlers) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+// Check is a HandlerFunc which checks that logs of the server can be read and returns result of every step
+func (s *ServerHandlers) Check(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := s.serverService.Check(r.Context(), id)
+
+	if err != nil {
+		slog.Error("Unexpected error", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func (s *ServerHandlers) createDryRun(w http.ResponseWriter, r *http.Request, data service.ServerData) {
+	response, err := s.serverService.CreateDryRun(r.Context(), data)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeOkJson(w, response)
+}
+
+func (s *ServerHandlers) updateDryRun(w http.ResponseWriter, r *http.Request, id int, data service.ServerData) {
+	response, err := s.serverService.UpdateDryRun(r.Context(), id, data)
+
+	if errors.As(err, &service.ErrValidation{}) {
+		writeValidationJson(w, err.(service.ErrValidation))
+		return
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if response == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
 	writeOkJson(w, response)
 }
+
+// isDryRun shows whether the request must be only validated and checked without saving
+func isDryRun(r *http.Request) bool {
+	return r.URL.Query().Get("dryRun") == "true"
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// accessLogPatterns are predefined access log formats of nginx and apache written in nginx log_format syntax
+var accessLogPatterns = map[entity.LogFormat]string{
+	entity.LogLocationFormatNginxCombined:  `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`,
+	entity.LogLocationFormatNginxMain:      `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent" "$http_x_forwarded_for"`,
+	entity.LogLocationFormatApacheCommon:   `$remote_addr $remote_ident $remote_user [$time_local] "$request" $status $body_bytes_sent`,
+	entity.LogLocationFormatApacheCombined: `$remote_addr $remote_ident $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`,
+}
+
+// accessLogFields renames variables of the log format to fields of the entry
+var accessLogFields = map[string]string{
+	"body_bytes_sent": "bytes",
+	"http_referer":    "referer",
+	"http_user_agent": "user_agent",
+	"request_method":  "method",
+	"request_uri":     "path",
+}
+
+// accessLogVariable matches $name and ${name} variables of the log format
+var accessLogVariable = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+
+// accessLogEscape matches \xHH sequences written by nginx and backslash escapes written by apache
+var accessLogEscape = regexp.MustCompile(`\\(x[0-9A-Fa-f]{2}|["\\])`)
+
+var errNotAccessLog = errors.New("line does not match access log format")
+
+// An accessLogParser parses lines of access log, values of the format variables become typed fields,
+// the request line is split to method, path and protocol and the level is derived from the status
+type accessLogParser struct {
+	re    *regexp.Regexp
+	names []string
+}
+
+// newAccessLogParser compiles log format of nginx log_format syntax, every variable matches the shortest text
+// followed by the rest of the format
+func newAccessLogParser(format string) (LogParser, error) {
+	variables := accessLogVariable.FindAllStringSubmatchIndex(format, -1)
+
+	if len(variables) == 0 {
+		return nil, errors.New("log format has no variables")
+	}
+
+	var (
+		b     strings.Builder
+		names []string
+		last  int
+	)
+
+	b.WriteString("^")
+
+	for _, v := range variables {
+		b.WriteString(regexp.QuoteMeta(format[last:v[0]]))
+		b.WriteString("(.*?)")
+
+		if v[2] != -1 {
+			names = append(names, format[v[2]:v[3]])
+		} else {
+			names = append(names, format[v[4]:v[5]])
+		}
+
+		last = v[1]
+	}
+
+	b.WriteString(regexp.QuoteMeta(format[last:]))
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+
+	if err != nil {
+		return nil, fmt.Errorf("can not compile log format: %w", err)
+	}
+
+	return accessLogParser{re: re, names: names}, nil
+}
+
+func (p accessLogParser) Parse(line string) (LogEntry, error) {
+	values := p.re.FindStringSubmatch(line)
+
+	if values == nil {
+		return LogEntry{}, errNotAccessLog
+	}
+
+	entry := LogEntry{Raw: line}
+	fields := map[string]any{}
+
+	for i, name := range p.names {
+		value := values[i+1]
+
+		if value == "" || value == "-" {
+			continue
+		}
+
+		value = unescapeAccessLog(value)
+
+		switch name {
+		case "time_local", "time_iso8601":
+			layout := accessLogTimeLayout
+
+			if name == "time_iso8601" {
+				layout = time.RFC3339
+			}
+
+			if t, err := time.Parse(layout, value); err == nil {
+				entry.Timestamp = &t
+				continue
+			}
+		case "request":
+			entry.Message = value
+
+			if method, rest, ok := strings.Cut(value, " "); ok {
+				path, protocol, _ := strings.Cut(rest, " ")
+
+				fields["method"] = method
+				fields["path"] = path
+
+				if protocol != "" {
+					fields["protocol"] = protocol
+				}
+			}
+
+			continue
+		}
+
+		if field, ok := accessLogFields[name]; ok {
+			name = field
+		}
+
+		fields[name] = accessLogValue(name, value)
+	}
+
+	if entry.Message == "" && fields["method"] != nil && fields["path"] != nil {
+		entry.Message = fmt.Sprintf("%s %s", fields["method"], fields["path"])
+	}
+
+	if status, ok := fields["status"].(int); ok {
+		entry.Level = statusLevel(status)
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+
+	return entry, nil
+}
+
+// accessLogValue converts values of numeric variables, values which can not be converted are kept as strings
+func accessLogValue(name, value string) any {
+	switch name {
+	case "status", "bytes", "bytes_sent", "request_length", "connection", "connection_requests":
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case "request_time", "upstream_response_time", "upstream_connect_time", "upstream_header_time", "msec":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
+
+func unescapeAccessLog(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	return accessLogEscape.ReplaceAllStringFunc(value, func(s string) string {
+		if s[1] != 'x' {
+			return s[1:]
+		}
+
+		b, _ := strconv.ParseUint(s[2:], 16, 8)
+
+		return string([]byte{byte(b)})
+	})
+}
+
+// statusLevel returns level of the request by its HTTP status
+func statusLevel(status int) string {
+	switch {
+	case status >= 500:
+		return "error"
+	case status >= 400:
+		return "warning"
+	}
+
+	return "info"
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestNewAccessLogParser(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		line      string
+		timestamp string
+		level     string
+		message   string
+		fields    map[string]any
+	}{
+		{
+			name:      "nginx combined",
+			format:    accessLogPatterns[entity.LogLocationFormatNginxCombined],
+			line:      `203.0.113.7 - alice [18/Oct/2026:10:00:00 +0200] "GET /index.html?q=1 HTTP/1.1" 200 512 "https://example.com/" "curl/8.0"`,
+			timestamp: "2026-10-18T10:00:00+02:00",
+			level:     "info",
+			message:   "GET /index.html?q=1 HTTP/1.1",
+			fields: map[string]any{
+				"remote_addr": "203.0.113.7",
+				"remote_user": "alice",
+				"method":      "GET",
+				"path":        "/index.html?q=1",
+				"protocol":    "HTTP/1.1",
+				"status":      200,
+				"bytes":       512,
+				"referer":     "https://example.com/",
+				"user_agent":  "curl/8.0",
+			},
+		},
+		{
+			name:      "apache common with missing values",
+			format:    accessLogPatterns[entity.LogLocationFormatApacheCommon],
+			line:      `10.0.0.1 - - [18/Oct/2026:10:00:00 +0000] "POST /login HTTP/1.0" 503 -`,
+			timestamp: "2026-10-18T10:00:00Z",
+			level:     "error",
+			message:   "POST /login HTTP/1.0",
+			fields:    map[string]any{"remote_addr": "10.0.0.1", "method": "POST", "path": "/login", "protocol": "HTTP/1.0", "status": 503},
+		},
+		{
+			name:      "escaped request",
+			format:    accessLogPatterns[entity.LogLocationFormatNginxCombined],
+			line:      `10.0.0.1 - - [18/Oct/2026:10:00:00 +0000] "GET /\x22quoted\x22 HTTP/1.1" 404 0 "-" "agent \"x\""`,
+			timestamp: "2026-10-18T10:00:00Z",
+			level:     "warning",
+			message:   `GET /"quoted" HTTP/1.1`,
+			fields:    map[string]any{"remote_addr": "10.0.0.1", "method": "GET", "path": `/"quoted"`, "protocol": "HTTP/1.1", "status": 404, "bytes": 0, "user_agent": `agent "x"`},
+		},
+		{
+			name:      "custom nginx format",
+			format:    `${time_iso8601} $request_method $request_uri $status $request_time`,
+			line:      `2026-10-18T10:00:00+00:00 DELETE /items/1 204 0.012`,
+			timestamp: "2026-10-18T10:00:00Z",
+			level:     "info",
+			message:   "DELETE /items/1",
+			fields:    map[string]any{"method": "DELETE", "path": "/items/1", "status": 204, "request_time": 0.012},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := newAccessLogParser(tt.format)
+
+			if err != nil {
+				t.Fatalf("newAccessLogParser() error = %v", err)
+			}
+
+			entry, err := parser.Parse(tt.line)
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if entry.Timestamp == nil || !entry.Timestamp.Equal(mustParseTime(t, tt.timestamp)) {
+				t.Errorf("Parse() timestamp = %v, want %s", entry.Timestamp, tt.timestamp)
+			}
+
+			if entry.Level != tt.level || entry.Message != tt.message {
+				t.Errorf("Parse() level = %q, message = %q, want %q, %q", entry.Level, entry.Message, tt.level, tt.message)
+			}
+
+			if !reflect.DeepEqual(entry.Fields, tt.fields) {
+				t.Errorf("Parse() fields = %v, want %v", entry.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestNewAccessLogParserErrors(t *testing.T) {
+	if _, err := newAccessLogParser("no variables"); err == nil {
+		t.Error("newAccessLogParser() accepted format without variables")
+	}
+
+	parser, err := newAccessLogParser(accessLogPatterns[entity.LogLocationFormatApacheCommon])
+
+	if err != nil {
+		t.Fatalf("newAccessLogParser() error = %v", err)
+	}
+
+	if _, err := parser.Parse("not an access log line"); err == nil {
+		t.Error("Parse() accepted line which does not match the format")
+	}
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
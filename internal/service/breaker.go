@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateOpen     = "open"
+	BreakerStateHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned when connections to the Server are not attempted after repeated failures
+var ErrCircuitOpen = errors.New("circuit breaker of the server is open")
+
+// A BreakerStatus is a state of circuit breaker of the Server, Failures is a number of consecutive connection failures
+type BreakerStatus struct {
+	State     string `json:"state"`
+	Failures  int    `json:"failures"`
+	OpenUntil string `json:"openUntil,omitempty"`
+}
+
+// circuitBreakers stop connecting to servers which failed threshold times in a row, after cooldown
+// the single probe connection is allowed, its success closes the breaker and failure opens it again,
+// zero threshold disables breakers
+type circuitBreakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[int]*circuitBreaker
+}
+
+type circuitBreaker struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreakers(threshold int, cooldown time.Duration) *circuitBreakers {
+	return &circuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  map[int]*circuitBreaker{},
+	}
+}
+
+// allow method returns ErrCircuitOpen when connection to the Server must not be attempted
+func (b *circuitBreakers) allow(serverId int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[serverId]
+
+	if !ok || breaker.openUntil.IsZero() {
+		return nil
+	}
+
+	if time.Now().Before(breaker.openUntil) || breaker.probing {
+		return fmt.Errorf("%w after %d failures, next attempt at %s", ErrCircuitOpen, breaker.failures, breaker.openUntil.Format(time.RFC3339))
+	}
+
+	breaker.probing = true
+
+	return nil
+}
+
+// success method closes breaker of the Server
+func (b *circuitBreakers) success(serverId int) {
+	b.reset(serverId)
+}
+
+// failure method counts failed connection, breaker is opened when the threshold is reached
+func (b *circuitBreakers) failure(serverId int) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[serverId]
+
+	if !ok {
+		breaker = &circuitBreaker{}
+		b.breakers[serverId] = breaker
+	}
+
+	breaker.failures++
+	breaker.probing = false
+
+	if breaker.failures >= b.threshold {
+		breaker.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// abort method releases the probe which was interrupted before its result is known
+func (b *circuitBreakers) abort(serverId int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if breaker, ok := b.breakers[serverId]; ok {
+		breaker.probing = false
+	}
+}
+
+// reset method forgets failures of the Server
+func (b *circuitBreakers) reset(serverId int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.breakers, serverId)
+}
+
+func (b *circuitBreakers) status(serverId int) BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker, ok := b.breakers[serverId]
+
+	if !ok {
+		return BreakerStatus{State: BreakerStateClosed}
+	}
+
+	status := BreakerStatus{State: BreakerStateClosed, Failures: breaker.failures}
+
+	if !breaker.openUntil.IsZero() {
+		status.State = BreakerStateOpen
+		status.OpenUntil = breaker.openUntil.Format(time.RFC3339)
+
+		if breaker.probing || !time.Now().Before(breaker.openUntil) {
+			status.State = BreakerStateHalfOpen
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakers(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		cooldown  time.Duration
+		events    []string
+		wantErr   error
+		wantState string
+		wantFails int
+	}{
+		{name: "no failures", threshold: 2, cooldown: time.Hour, wantState: BreakerStateClosed},
+		{name: "failures below threshold", threshold: 2, cooldown: time.Hour, events: []string{"failure"}, wantState: BreakerStateClosed, wantFails: 1},
+		{name: "threshold reached", threshold: 2, cooldown: time.Hour, events: []string{"failure", "failure"}, wantErr: ErrCircuitOpen, wantState: BreakerStateOpen, wantFails: 2},
+		{name: "success resets failures", threshold: 2, cooldown: time.Hour, events: []string{"failure", "success", "failure"}, wantState: BreakerStateClosed, wantFails: 1},
+		{name: "disabled breaker", cooldown: time.Hour, events: []string{"failure", "failure", "failure"}, wantState: BreakerStateClosed},
+		{name: "cooldown passed", threshold: 1, events: []string{"failure"}, wantState: BreakerStateHalfOpen, wantFails: 1},
+		{name: "single probe after cooldown", threshold: 1, events: []string{"failure", "allow"}, wantErr: ErrCircuitOpen, wantState: BreakerStateHalfOpen, wantFails: 1},
+		{name: "aborted probe", threshold: 1, events: []string{"failure", "allow", "abort"}, wantState: BreakerStateHalfOpen, wantFails: 1},
+		{name: "failures keep breaker open", threshold: 1, cooldown: time.Hour, events: []string{"failure", "failure"}, wantErr: ErrCircuitOpen, wantState: BreakerStateOpen, wantFails: 2},
+		{name: "succeeded probe", threshold: 1, events: []string{"failure", "allow", "success"}, wantState: BreakerStateClosed},
+		{name: "reset", threshold: 1, cooldown: time.Hour, events: []string{"failure", "reset"}, wantState: BreakerStateClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreakers(tt.threshold, tt.cooldown)
+
+			for _, event := range tt.events {
+				switch event {
+				case "allow":
+					if err := b.allow(1); err != nil {
+						t.Fatalf("allow() error = %v", err)
+					}
+				case "success":
+					b.success(1)
+				case "failure":
+					b.failure(1)
+				case "abort":
+					b.abort(1)
+				case "reset":
+					b.reset(1)
+				}
+			}
+
+			status := b.status(1)
+
+			if status.State != tt.wantState || status.Failures != tt.wantFails {
+				t.Errorf("status() = %+v, want state %s and %d failures", status, tt.wantState, tt.wantFails)
+			}
+
+			if err := b.allow(1); !errors.Is(err, tt.wantErr) {
+				t.Errorf("allow() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if other := b.status(2); other.State != BreakerStateClosed {
+				t.Errorf("status() of another server = %+v, want closed", other)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// certificateExpiryWarning is the longest period before the expiry when certificate is reported,
+// short-lived certificates are reported during the last fifth of their validity
+const certificateExpiryWarning = 24 * time.Hour
+
+// A CertificateResponse describes OpenSSH user certificate of the Credential,
+// empty ValidAfter and ValidBefore mean that the certificate is not limited in time
+type CertificateResponse struct {
+	KeyId       string   `json:"keyId"`
+	Principals  []string `json:"principals"`
+	ValidAfter  string   `json:"validAfter,omitempty"`
+	ValidBefore string   `json:"validBefore,omitempty"`
+}
+
+// parseCertificate parses OpenSSH user certificate in authorized_keys format
+func parseCertificate(certificate string) (*ssh.Certificate, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+
+	if err != nil {
+		return nil, fmt.Errorf("can not parse certificate: %w", err)
+	}
+
+	cert, ok := key.(*ssh.Certificate)
+
+	if !ok {
+		return nil, fmt.Errorf("%s is a public key, not a certificate", key.Type())
+	}
+
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("certificate is a host certificate, user certificate is expected")
+	}
+
+	return cert, nil
+}
+
+// certificateSigner returns signer which offers the certificate instead of the plain public key
+func certificateSigner(signer ssh.Signer, certificate string) (ssh.Signer, error) {
+	cert, err := parseCertificate(certificate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(cert.Key.Marshal(), signer.PublicKey().Marshal()) {
+		return nil, fmt.Errorf("certificate is not issued for the private key")
+	}
+
+	return ssh.NewCertSigner(cert, signer)
+}
+
+func createCertificateResponse(cert *ssh.Certificate) *CertificateResponse {
+	response := &CertificateResponse{KeyId: cert.KeyId, Principals: cert.ValidPrincipals}
+
+	if response.Principals == nil {
+		response.Principals = []string{}
+	}
+
+	if cert.ValidAfter != 0 {
+		response.ValidAfter = certificateTime(cert.ValidAfter).Format(time.RFC3339)
+	}
+
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		response.ValidBefore = certificateTime(cert.ValidBefore).Format(time.RFC3339)
+	}
+
+	return response
+}
+
+// certificateWarning returns warning about the certificate which is expired, expires soon or is not valid yet,
+// empty string means the certificate is valid at the moment
+func certificateWarning(cert *ssh.Certificate, now time.Time) string {
+	validAfter := certificateTime(cert.ValidAfter)
+
+	if now.Before(validAfter) {
+		return fmt.Sprintf("certificate is not valid before %s", validAfter.Format(time.RFC3339))
+	}
+
+	if cert.ValidBefore == ssh.CertTimeInfinity {
+		return ""
+	}
+
+	validBefore := certificateTime(cert.ValidBefore)
+
+	if !now.Before(validBefore) {
+		return fmt.Sprintf("certificate expired at %s", validBefore.Format(time.RFC3339))
+	}
+
+	threshold := certificateExpiryWarning
+
+	if validity := validBefore.Sub(validAfter) / 5; cert.ValidAfter != 0 && validity < threshold {
+		threshold = validity
+	}
+
+	if validBefore.Sub(now) <= threshold {
+		return fmt.Sprintf("certificate expires at %s", validBefore.Format(time.RFC3339))
+	}
+
+	return ""
+}
+
+func certificateTime(t uint64) time.Time {
+	return time.Unix(int64(t), 0).UTC()
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCertificateWarning(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		validAfter  time.Time
+		validBefore time.Time
+		// infinite certificate has no ValidBefore
+		infinite bool
+		want     string
+	}{
+		{name: "not limited in time", infinite: true},
+		{name: "valid", validAfter: now.Add(-30 * 24 * time.Hour), validBefore: now.Add(30 * 24 * time.Hour)},
+		{name: "not valid yet", validAfter: now.Add(time.Hour), infinite: true, want: "certificate is not valid before 2026-10-18T13:00:00Z"},
+		{name: "expired", validAfter: now.Add(-48 * time.Hour), validBefore: now.Add(-time.Hour), want: "certificate expired at 2026-10-18T11:00:00Z"},
+		{name: "expires at the moment", validAfter: now.Add(-48 * time.Hour), validBefore: now, want: "certificate expired at 2026-10-18T12:00:00Z"},
+		{name: "expires within a day", validAfter: now.Add(-30 * 24 * time.Hour), validBefore: now.Add(23 * time.Hour), want: "certificate expires at 2026-10-19T11:00:00Z"},
+		{name: "expires in more than a day", validAfter: now.Add(-30 * 24 * time.Hour), validBefore: now.Add(25 * time.Hour)},
+		{name: "without start expires within a day", validBefore: now.Add(23 * time.Hour), want: "certificate expires at 2026-10-19T11:00:00Z"},
+		{name: "short-lived in the last fifth", validAfter: now.Add(-9 * time.Hour), validBefore: now.Add(time.Hour), want: "certificate expires at 2026-10-18T13:00:00Z"},
+		{name: "short-lived before the last fifth", validAfter: now.Add(-7 * time.Hour), validBefore: now.Add(3 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &ssh.Certificate{ValidBefore: ssh.CertTimeInfinity}
+
+			if !tt.validAfter.IsZero() {
+				cert.ValidAfter = uint64(tt.validAfter.Unix())
+			}
+
+			if !tt.infinite {
+				cert.ValidBefore = uint64(tt.validBefore.Unix())
+			}
+
+			if got := certificateWarning(cert, now); got != tt.want {
+				t.Errorf("certificateWarning() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateSigner(t *testing.T) {
+	signer := newTestSigner(t)
+	other := newTestSigner(t)
+
+	tests := []struct {
+		name        string
+		certificate func() string
+		wantErr     string
+	}{
+		{
+			name:        "user certificate of the key",
+			certificate: func() string { return signTestCertificate(t, signer.PublicKey(), ssh.UserCert) },
+		},
+		{
+			name:        "certificate of another key",
+			certificate: func() string { return signTestCertificate(t, other.PublicKey(), ssh.UserCert) },
+			wantErr:     "certificate is not issued for the private key",
+		},
+		{
+			name:        "host certificate",
+			certificate: func() string { return signTestCertificate(t, signer.PublicKey(), ssh.HostCert) },
+			wantErr:     "certificate is a host certificate",
+		},
+		{
+			name:        "public key",
+			certificate: func() string { return pinnedPublicKey(signer.PublicKey()) },
+			wantErr:     "is a public key, not a certificate",
+		},
+		{
+			name:        "malformed certificate",
+			certificate: func() string { return "ssh-ed25519-cert-v01@openssh.com AAAA" },
+			wantErr:     "can not parse certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := certificateSigner(signer, tt.certificate())
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("certificateSigner() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("certificateSigner() error = %v", err)
+			}
+
+			if _, ok := got.PublicKey().(*ssh.Certificate); !ok {
+				t.Errorf("certificateSigner() offers %s, want certificate", got.PublicKey().Type())
+			}
+		})
+	}
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("can not generate key: %v", err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(private)
+
+	if err != nil {
+		t.Fatalf("can not create signer: %v", err)
+	}
+
+	return signer
+}
+
+// signTestCertificate returns the certificate of the key signed by new certificate authority in authorized_keys format
+func signTestCertificate(t *testing.T, key ssh.PublicKey, certType uint32) string {
+	t.Helper()
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        certType,
+		KeyId:           "deploy",
+		ValidPrincipals: []string{"deploy"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+
+	if err := cert.SignCert(rand.Reader, newTestSigner(t)); err != nil {
+		t.Fatalf("can not sign certificate: %v", err)
+	}
+
+	return string(ssh.MarshalAuthorizedKey(cert))
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const (
+	CheckStepDns  = "dns"
+	CheckStepTcp  = "tcp"
+	CheckStepSsh  = "ssh"
+	CheckStepRead = "read"
+
+	CheckStatusOk      = "ok"
+	CheckStatusFailed  = "failed"
+	CheckStatusSkipped = "skipped"
+)
+
+// A CheckStep is a result of a single connectivity check step, Cause classifies the error,
+// Warning reports a problem which does not fail the step yet
+type CheckStep struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"durationMs"`
+	Cause      string `json:"cause,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Details    string `json:"details,omitempty"`
+	Warning    string `json:"warning,omitempty"`
+}
+
+type CheckResponse struct {
+	Ok    bool        `json:"ok"`
+	Steps []CheckStep `json:"steps"`
+}
+
+// A ServerChecker checks that logman is able to read logs of the Server step by step:
+// resolves the host, connects to ssh port, authenticates and reads every LogLocation
+type ServerChecker struct {
+	connector       *SSHConnector
+	pool            *ConnectionPool
+	locationStorage LogLocationStorager
+	localRoots      []string
+}
+
+func NewServerChecker(connector *SSHConnector, pool *ConnectionPool, locationStorage LogLocationStorager, localRoots []string) *ServerChecker {
+	return &ServerChecker{
+		connector:       connector,
+		pool:            pool,
+		locationStorage: locationStorage,
+		localRoots:      localRoots,
+	}
+}
+
+// Check method runs every step, steps after the failed one are skipped, the stored Server is connected
+// through the pool, so the check obeys its limits and circuit breaker, in dry run mode the Server
+// is connected directly and its host key is not pinned
+func (c *ServerChecker) Check(ctx context.Context, server entity.Server, dryRun bool) CheckResponse {
+	addr := serverAddr(server)
+	local := server.Kind == entity.ServerKindLocal
+
+	var client *sessionClient
+
+	release := func() {}
+
+	var warning string
+
+	steps := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{CheckStepDns, func() (string, error) {
+			if local {
+				return localSkipDetails, errCheckSkipped
+			}
+
+			if server.JumpServerId != 0 {
+				return jumpSkipDetails, errCheckSkipped
+			}
+
+			addrs, err := net.DefaultResolver.LookupHost(ctx, server.Host)
+
+			return strings.Join(addrs, ", "), err
+		}},
+		{CheckStepTcp, func() (string, error) {
+			if local {
+				return localSkipDetails, errCheckSkipped
+			}
+
+			if server.JumpServerId != 0 {
+				return jumpSkipDetails, errCheckSkipped
+			}
+
+			conn, err := (&net.Dialer{Timeout: connectTimeout(server)}).DialContext(ctx, "tcp", addr)
+
+			if err != nil {
+				return "", err
+			}
+
+			return conn.RemoteAddr().String(), conn.Close()
+		}},
+		{CheckStepSsh, func() (string, error) {
+			if local {
+				return localSkipDetails, errCheckSkipped
+			}
+
+			var err error
+
+			if warning, err = c.credentialWarning(ctx, server); err != nil {
+				return "", err
+			}
+
+			client, release, err = c.connect(ctx, server, dryRun)
+
+			if err != nil {
+				return "", err
+			}
+
+			return string(client.client.ServerVersion()), nil
+		}},
+		{CheckStepRead, func() (string, error) {
+			locations, err := c.locations(ctx, server)
+
+			if err != nil {
+				return "", err
+			}
+
+			if len(locations) == 0 {
+				return "server has no log locations", nil
+			}
+
+			if local {
+				return checkLogLocations(ctx, newLocalLogReader(c.localRoots), locations)
+			}
+
+			sudo, err := serverPrivilege(ctx, c.connector.credentials, server)
+
+			if err != nil {
+				return "", err
+			}
+
+			reader, err := openLogReader(ctx, client, server, locations, sudo, c.connector.l)
+
+			if err != nil {
+				return "", err
+			}
+
+			defer reader.Close()
+
+			return checkLogLocations(ctx, reader, locations)
+		}},
+	}
+
+	defer func() {
+		release()
+	}()
+
+	response := CheckResponse{Ok: true}
+
+	for _, step := range steps {
+		result := CheckStep{Name: step.name}
+
+		if !response.Ok {
+			result.Status = CheckStatusSkipped
+			response.Steps = append(response.Steps, result)
+			continue
+		}
+
+		start := time.Now()
+		details, err := step.run()
+		result.DurationMs = time.Since(start).Milliseconds()
+		result.Details = details
+		result.Warning, warning = warning, ""
+
+		switch {
+		case errors.Is(err, errCheckSkipped):
+			result.Status = CheckStatusSkipped
+		case err != nil:
+			response.Ok = false
+			result.Status = CheckStatusFailed
+			result.Cause = checkCause(err)
+			result.Error = err.Error()
+		default:
+			result.Status = CheckStatusOk
+		}
+
+		response.Steps = append(response.Steps, result)
+	}
+
+	return response
+}
+
+const (
+	jumpSkipDetails  = "host is resolved and connected by jump server"
+	localSkipDetails = "local server is read from the disk"
+)
+
+var errCheckSkipped = errors.New("check step is skipped")
+
+// connect method returns pooled client of the Server, in dry run mode the Server is connected directly
+// and the returned func closes the connection
+func (c *ServerChecker) connect(ctx context.Context, server entity.Server, dryRun bool) (*sessionClient, func(), error) {
+	if !dryRun {
+		return c.pool.Acquire(ctx, server)
+	}
+
+	client, err := c.connector.connect(ctx, server, true)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newSessionClient(client), func() { client.Close() }, nil
+}
+
+// credentialWarning method returns warning about Credential of the Server, like its certificate expiring soon
+func (c *ServerChecker) credentialWarning(ctx context.Context, server entity.Server) (string, error) {
+	credential, err := c.connector.credentials.GetById(ctx, server.CredentialId)
+
+	if err != nil {
+		return "", fmt.Errorf("error during Credential search by id: %w", err)
+	}
+
+	if credential == nil {
+		return "", nil
+	}
+
+	return credentialWarning(*credential), nil
+}
+
+// locations method returns LogLocations of the stored Server, Server which is not saved yet has no locations
+func (c *ServerChecker) locations(ctx context.Context, server entity.Server) ([]entity.LogLocation, error) {
+	if server.Id == 0 {
+		return nil, nil
+	}
+
+	locations, err := c.locationStorage.GetListByServerId(ctx, server.Id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during LogLocation search by server id: %w", err)
+	}
+
+	return locations, nil
+}
+
+// checkLogLocations reads every location by the reader, the first failed location fails the check
+func checkLogLocations(ctx context.Context, reader LogReader, locations []entity.LogLocation) (string, error) {
+	details := make([]string, 0, len(locations))
+
+	for _, location := range locations {
+		detail, err := checkLogLocation(ctx, reader, location)
+
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", location.Name, err)
+		}
+
+		details = append(details, fmt.Sprintf("%s: %s", location.Name, detail))
+	}
+
+	return strings.Join(details, "; "), nil
+}
+
+// checkLogLocation reads the location by the reader
+func checkLogLocation(ctx context.Context, reader LogReader, location entity.LogLocation) (string, error) {
+	switch location.Kind {
+	case entity.LogLocationKindJournald:
+		return checkJournal(ctx, reader, location)
+	case entity.LogLocationKindDocker:
+		return checkDocker(ctx, reader, location)
+	}
+
+	return checkLogFiles(ctx, reader, location.Path)
+}
+
+// checkLogFiles lists files matched by the pattern and reads the beginning of the first file, so permission problems are detected
+func checkLogFiles(ctx context.Context, reader LogReader, pattern string) (string, error) {
+	groups, err := globRotations(ctx, reader, pattern)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(groups) == 0 {
+		return "", fmt.Errorf("%w: no files match %s", os.ErrNotExist, pattern)
+	}
+
+	first := groups[0].files[len(groups[0].files)-1]
+
+	rc, err := reader.Open(ctx, first.path)
+
+	if err == nil {
+		rc, err = first.decompress(rc)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.ReadFull(rc, make([]byte, 1))
+
+	if closeErr := rc.Close(); err == nil || errors.Is(err, io.EOF) {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("can not read %s: %w", first.path, err)
+	}
+
+	rotated := 0
+
+	for _, group := range groups {
+		rotated += len(group.files) - 1
+	}
+
+	return fmt.Sprintf("%d files found over %s, %d rotated", len(groups), reader.Transport(), rotated), nil
+}
+
+// checkCause classifies error of the check step
+func checkCause(err error) string {
+	var dnsErr *net.DNSError
+
+	msg := err.Error()
+
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "host_not_found"
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) || strings.Contains(msg, "i/o timeout"):
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH):
+		return "host_unreachable"
+	case errors.Is(err, ErrPrivilegeDenied):
+		return "sudo_denied"
+	case errors.Is(err, ErrHostKeyMismatch):
+		return "host_key_mismatch"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, errJumpServerNotFound) || errors.Is(err, errJumpChainCycle) || errors.Is(err, errJumpServerLocal):
+		return "jump_server_invalid"
+	case strings.Contains(msg, "unable to authenticate"):
+		return "auth_failed"
+	case strings.Contains(msg, "private key") || strings.Contains(msg, "ssh-agent"):
+		return "credential_invalid"
+	case errors.Is(err, os.ErrPermission) || strings.Contains(msg, "Permission denied"):
+		return "permission_denied"
+	case errors.Is(err, os.ErrNotExist) || strings.Contains(msg, "No such file or directory"):
+		return "not_found"
+	}
+
+	return "unknown"
+}
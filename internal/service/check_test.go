@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestCheckCause(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "unknown host", err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, want: "host_not_found"},
+		{name: "deadline", err: fmt.Errorf("dial: %w", context.DeadlineExceeded), want: "timeout"},
+		{name: "io timeout", err: errors.New("dial tcp 10.0.0.1:22: i/o timeout"), want: "timeout"},
+		{name: "refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: "connection_refused"},
+		{name: "unreachable", err: fmt.Errorf("dial: %w", syscall.EHOSTUNREACH), want: "host_unreachable"},
+		{name: "sudo", err: fmt.Errorf("%w: sudo: a password is required", ErrPrivilegeDenied), want: "sudo_denied"},
+		{name: "host key", err: fmt.Errorf("%w: offered another key", ErrHostKeyMismatch), want: "host_key_mismatch"},
+		{name: "circuit breaker", err: fmt.Errorf("%w after 3 failures", ErrCircuitOpen), want: "circuit_open"},
+		{name: "jump server", err: fmt.Errorf("%w: 7", errJumpServerNotFound), want: "jump_server_invalid"},
+		{name: "authentication", err: errors.New("ssh: handshake failed: ssh: unable to authenticate"), want: "auth_failed"},
+		{name: "private key", err: errors.New("can not parse private key"), want: "credential_invalid"},
+		{name: "permission", err: fmt.Errorf("open: %w", os.ErrPermission), want: "permission_denied"},
+		{name: "remote permission", err: errors.New("cat: /var/log/auth.log: Permission denied"), want: "permission_denied"},
+		{name: "not found", err: fmt.Errorf("open: %w", os.ErrNotExist), want: "not_found"},
+		{name: "other", err: errors.New("broken pipe"), want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkCause(tt.err); got != tt.want {
+				t.Errorf("checkCause(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerCheckerCheckLocal(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, "app.log"), []byte("started\n"), 0o644); err != nil {
+		t.Fatalf("can not write log file: %v", err)
+	}
+
+	server := entity.Server{Id: 1, Name: "local", Kind: entity.ServerKindLocal}
+
+	tests := []struct {
+		name      string
+		path      string
+		wantOk    bool
+		wantRead  string
+		wantCause string
+	}{
+		{name: "readable location", path: filepath.Join(root, "*.log"), wantOk: true, wantRead: CheckStatusOk},
+		{name: "missing location", path: filepath.Join(root, "*.txt"), wantRead: CheckStatusFailed, wantCause: "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations := &memoryLocationStorage{locations: []entity.LogLocation{
+				{Id: 1, ServerId: server.Id, Name: "app", Kind: entity.LogLocationKindFile, Path: tt.path},
+			}}
+
+			response := NewServerChecker(nil, nil, locations, []string{root}).Check(context.Background(), server, false)
+
+			if response.Ok != tt.wantOk || len(response.Steps) != 4 {
+				t.Fatalf("Check() = %+v, want ok %v", response, tt.wantOk)
+			}
+
+			for _, step := range response.Steps[:3] {
+				if step.Status != CheckStatusSkipped || step.Details != localSkipDetails {
+					t.Errorf("Check() step %s = %+v, want skipped", step.Name, step)
+				}
+			}
+
+			if read := response.Steps[3]; read.Status != tt.wantRead || read.Cause != tt.wantCause {
+				t.Errorf("Check() step read = %+v, want %s with cause %q", read, tt.wantRead, tt.wantCause)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/krasilnikovm/logman/internal/entity"
@@ -13,32 +15,83 @@ type CredentialStorager interface {
 	GetById(ctx context.Context, id int) (*entity.Credential, error)
 	GetList(ctx context.Context, page, limit int) ([]*entity.Credential, error)
 	DeleteById(ctx context.Context, id int) error
+	ReassignAndDeleteById(ctx context.Context, id, reassignTo int) error
 	Update(ctx context.Context, credential *entity.Credential) error
 }
 
+// ErrCredentialInUse is returned when deleted Credential is used by servers
+type ErrCredentialInUse struct {
+	Servers []ServerReference `json:"servers"`
+}
+
+func (e ErrCredentialInUse) Error() string {
+	return fmt.Sprintf("credential is used by %d servers", len(e.Servers))
+}
+
+// A CredentialData contains credential fields, set of required fields depends on the Kind,
+// secret fields omitted on update keep their previous values while the Kind is not changed,
+// secret fields passed as empty strings are cleared
 type CredentialData struct {
-	Name string `json:"name"`
-	Path string `json:"path"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Path        string  `json:"path"`
+	Passphrase  *string `json:"passphrase"`
+	Password    *string `json:"password"`
+	AgentSocket string  `json:"agentSocket"`
+	PrivateKey  *string `json:"privateKey"`
+	Certificate string  `json:"certificate"`
 }
 
+// A CredentialResponse never contains secret fields of the credential,
+// Warnings report problems of the credential which will break connections soon
 type CredentialResponse struct {
-	Id        int    `json:"id"`
+	Id          int                  `json:"id"`
+	Name        string               `json:"name"`
+	Kind        string               `json:"kind"`
+	Path        string               `json:"path,omitempty"`
+	AgentSocket string               `json:"agentSocket,omitempty"`
+	Certificate *CertificateResponse `json:"certificate,omitempty"`
+	Warnings    []string             `json:"warnings,omitempty"`
+	CreatedAt   string               `json:"createdAt"`
+	UpdatedAt   string               `json:"updatedAt"`
+}
+
+// A CredentialGenerateData describes key pair generated by logman, empty Algorithm means ed25519,
+// From, SftpOnly and JumpHost are used only for the suggested authorized_keys line,
+// JumpHost keeps port forwarding allowed for keys of servers used as jump servers
+type CredentialGenerateData struct {
 	Name      string `json:"name"`
-	Path      string `json:"path"`
-	CreatedAt string `json:"createdAt"`
-	UpdatedAt string `json:"updatedAt"`
+	Algorithm string `json:"algorithm"`
+	Comment   string `json:"comment"`
+	From      string `json:"from"`
+	SftpOnly  bool   `json:"sftpOnly"`
+	JumpHost  bool   `json:"jumpHost"`
+}
+
+// A CredentialGenerateResponse contains created inline key Credential and its public key,
+// AuthorizedKey is a PublicKey with suggested restrictions which should be added to authorized_keys of the Server
+type CredentialGenerateResponse struct {
+	Credential    CredentialResponse `json:"credential"`
+	PublicKey     string             `json:"publicKey"`
+	AuthorizedKey string             `json:"authorizedKey"`
 }
 
 type CredentialService struct {
-	storage CredentialStorager
+	storage       CredentialStorager
+	serverStorage ServerStorager
+	connections   ConnectionManager
+	agentSockets  []string
 
 	validator Validator
 }
 
-func NewCredentialService(storage CredentialStorager, validator Validator) *CredentialService {
+func NewCredentialService(storage CredentialStorager, serverStorage ServerStorager, connections ConnectionManager, agentSockets []string, validator Validator) *CredentialService {
 	return &CredentialService{
-		storage:   storage,
-		validator: validator,
+		storage:       storage,
+		serverStorage: serverStorage,
+		connections:   connections,
+		agentSockets:  agentSockets,
+		validator:     validator,
 	}
 }
 
@@ -46,15 +99,12 @@ func (c *CredentialService) Create(ctx context.Context, data CredentialData) (Cr
 
 	now := time.Now()
 
-	credential := &entity.Credential{
-		Path:      entity.KeyPath(data.Path),
-		Name:      data.Name,
-		CreatedAt: now.Format(time.RFC3339),
-		UpdatedAt: now.Format(time.RFC3339),
-	}
+	credential := createCredentialEntityFromData(data)
+	credential.CreatedAt = now.Format(time.RFC3339)
+	credential.UpdatedAt = now.Format(time.RFC3339)
 
-	if err := c.validator.Struct(credential); err != nil {
-		return CredentialResponse{}, buildValidationError(err)
+	if err := c.validate(credential); err != nil {
+		return CredentialResponse{}, err
 	}
 
 	err := c.storage.Create(ctx, credential)
@@ -63,49 +113,175 @@ func (c *CredentialService) Create(ctx context.Context, data CredentialData) (Cr
 		return CredentialResponse{}, fmt.Errorf("error during Credential creation: %w", err)
 	}
 
-	response := CredentialResponse{
-		Id:        credential.Id,
-		Name:      credential.Name,
-		Path:      string(credential.Path),
-		CreatedAt: credential.CreatedAt,
-		UpdatedAt: credential.UpdatedAt,
+	return *createCredentialResponseFromEntity(*credential), nil
+}
+
+// Generate method creates new key pair and stores its private key as inline key Credential,
+// the private key never leaves logman, only the public key is returned
+func (c *CredentialService) Generate(ctx context.Context, data CredentialGenerateData) (*CredentialGenerateResponse, error) {
+	algorithm := data.Algorithm
+
+	if algorithm == "" {
+		algorithm = KeyAlgorithmEd25519
+	}
+
+	if algorithm != KeyAlgorithmEd25519 && algorithm != KeyAlgorithmRSA {
+		return nil, ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Algorithm' field, please check the 'Algorithm' is one of %s %s", KeyAlgorithmEd25519, KeyAlgorithmRSA)}}
+	}
+
+	if strings.ContainsAny(data.From, "\" \t\n") {
+		return nil, ErrValidation{Errors: []string{"invalid 'From' field, please check the 'From' is a comma separated list of hosts without spaces and quotes"}}
 	}
 
-	return response, nil
+	if strings.ContainsAny(data.Comment, "\n") {
+		return nil, ErrValidation{Errors: []string{"invalid 'Comment' field, please check the 'Comment' is a single line"}}
+	}
+
+	privateKey, publicKey, err := generateKeyPair(algorithm)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := c.Create(ctx, CredentialData{
+		Name:       data.Name,
+		Kind:       entity.CredentialKindInlineKey,
+		PrivateKey: &privateKey,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	comment := data.Comment
+
+	if comment == "" {
+		comment = "logman-" + strings.ReplaceAll(data.Name, " ", "-")
+	}
+
+	return &CredentialGenerateResponse{
+		Credential:    credential,
+		PublicKey:     authorizedKey(publicKey, comment, nil),
+		AuthorizedKey: authorizedKey(publicKey, comment, authorizedKeyOptions(data.From, data.SftpOnly, data.JumpHost)),
+	}, nil
 }
 
 func (c *CredentialService) Update(ctx context.Context, id int, data CredentialData) (*CredentialResponse, error) {
-	now := time.Now()
+	existing, err := c.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Credential search by id: %w", err)
+	}
 
-	credential := &entity.Credential{
-		Id:        id,
-		Name:      data.Name,
-		Path:      entity.KeyPath(data.Path),
-		UpdatedAt: now.Format(time.RFC3339),
-		CreatedAt: now.Format(time.RFC3339),
+	if existing == nil {
+		return nil, nil
 	}
 
-	if err := c.validator.Struct(credential); err != nil {
-		return nil, buildValidationError(err)
+	credential := createCredentialEntityFromData(data)
+	credential.Id = id
+	credential.CreatedAt = existing.CreatedAt
+	credential.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if credential.Kind == existing.Kind {
+		keepCredentialSecrets(credential, *existing, data)
 	}
 
-	err := c.storage.Update(ctx, credential)
+	if err := c.validate(credential); err != nil {
+		return nil, err
+	}
+
+	servers, err := c.serverStorage.GetListByCredentialId(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Server search by credential id: %w", err)
+	}
+
+	err = c.storage.Update(ctx, credential)
 
 	if err != nil {
 		return nil, fmt.Errorf("error during Credential update: %w", err)
 	}
 
+	c.invalidate(servers)
+
 	return c.GetById(ctx, id)
 }
 
-func (c *CredentialService) DeleteById(ctx context.Context, id int) error {
+// DeleteById method deletes Credential which is not used by servers, otherwise ErrCredentialInUse is returned,
+// non zero reassignTo moves the servers to another Credential before the deletion
+func (c *CredentialService) DeleteById(ctx context.Context, id, reassignTo int) error {
+	if reassignTo != 0 {
+		return c.reassignAndDeleteById(ctx, id, reassignTo)
+	}
+
+	if err := c.checkNotInUse(ctx, id); err != nil {
+		return err
+	}
+
 	if err := c.storage.DeleteById(ctx, id); err != nil {
+		// a server attached after the check makes the foreign key reject the deletion, it is reported as a conflict as well
+		if inUseErr := c.checkNotInUse(ctx, id); errors.As(inUseErr, &ErrCredentialInUse{}) {
+			return inUseErr
+		}
+
 		return fmt.Errorf("error during Credential deletion: %w", err)
 	}
 
 	return nil
 }
 
+// checkNotInUse method returns ErrCredentialInUse when the Credential is used by servers
+func (c *CredentialService) checkNotInUse(ctx context.Context, id int) error {
+	servers, err := c.serverStorage.GetListByCredentialId(ctx, id)
+
+	if err != nil {
+		return fmt.Errorf("error during Server search by credential id: %w", err)
+	}
+
+	if len(servers) == 0 {
+		return nil
+	}
+
+	return ErrCredentialInUse{Servers: createServerReferences(servers)}
+}
+
+func (c *CredentialService) reassignAndDeleteById(ctx context.Context, id, reassignTo int) error {
+	if reassignTo == id {
+		return ErrValidation{Errors: []string{"invalid 'reassignTo' parameter, credential can not be reassigned to itself"}}
+	}
+
+	credential, err := c.storage.GetById(ctx, reassignTo)
+
+	if err != nil {
+		return fmt.Errorf("error during Credential search by id: %w", err)
+	}
+
+	if credential == nil {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'reassignTo' parameter, credential with id %d not found", reassignTo)}}
+	}
+
+	servers, err := c.serverStorage.GetListByCredentialId(ctx, id)
+
+	if err != nil {
+		return fmt.Errorf("error during Server search by credential id: %w", err)
+	}
+
+	if err := c.storage.ReassignAndDeleteById(ctx, id, reassignTo); err != nil {
+		return fmt.Errorf("error during Credential deletion: %w", err)
+	}
+
+	c.invalidate(servers)
+
+	return nil
+}
+
+// invalidate method drops pooled connections of the servers, so they reconnect by the actual Credential
+func (c *CredentialService) invalidate(servers []entity.Server) {
+	for _, server := range servers {
+		c.connections.Invalidate(server.Id)
+	}
+}
+
 func (c *CredentialService) GetList(ctx context.Context, page, limit int) ([]CredentialResponse, error) {
 	credentials, err := c.storage.GetList(ctx, page, limit)
 
@@ -116,13 +292,7 @@ func (c *CredentialService) GetList(ctx context.Context, page, limit int) ([]Cre
 	responses := make([]CredentialResponse, len(credentials))
 
 	for i, credential := range credentials {
-		responses[i] = CredentialResponse{
-			Id:        credential.Id,
-			Name:      credential.Name,
-			Path:      string(credential.Path),
-			CreatedAt: credential.CreatedAt,
-			UpdatedAt: credential.UpdatedAt,
-		}
+		responses[i] = *createCredentialResponseFromEntity(*credential)
 	}
 
 	return responses, nil
@@ -139,13 +309,122 @@ func (c *CredentialService) GetById(ctx context.Context, id int) (*CredentialRes
 		return nil, nil
 	}
 
+	return createCredentialResponseFromEntity(*credential), nil
+}
+
+// validate method checks fields required by the credential kind, that agent socket is allowed
+// and that inline key material can be used
+func (c *CredentialService) validate(credential *entity.Credential) error {
+	if err := c.validator.Struct(credential); err != nil {
+		return buildValidationError(err)
+	}
+
+	if credential.Kind == entity.CredentialKindAgent && !agentSocketAllowed(c.agentSockets, credential.AgentSocket) {
+		return ErrValidation{Errors: []string{"invalid 'AgentSocket' field, please check the 'AgentSocket' is allowed by LOGMAN_AGENT_SOCKETS"}}
+	}
+
+	if credential.Kind == entity.CredentialKindInlineKey {
+		signer, err := parsePrivateKey([]byte(credential.PrivateKey), credential.Passphrase)
+
+		if err != nil {
+			return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'PrivateKey' field, %s", err)}}
+		}
+
+		if credential.Certificate != "" {
+			if _, err := certificateSigner(signer, credential.Certificate); err != nil {
+				return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Certificate' field, %s", err)}}
+			}
+		}
+	}
+
+	// key files are read only on connection, so the certificate is checked against the key there
+	if credential.Certificate != "" {
+		if _, err := parseCertificate(credential.Certificate); err != nil {
+			return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Certificate' field, %s", err)}}
+		}
+	}
+
+	return nil
+}
+
+func createCredentialEntityFromData(data CredentialData) *entity.Credential {
+	kind := entity.CredentialKind(data.Kind)
+
+	// credentials created before kinds were introduced are always key files
+	if kind == "" {
+		kind = entity.CredentialKindKey
+	}
+
+	return &entity.Credential{
+		Name:        data.Name,
+		Kind:        kind,
+		Path:        entity.KeyPath(data.Path),
+		Passphrase:  stringValue(data.Passphrase),
+		Password:    stringValue(data.Password),
+		AgentSocket: data.AgentSocket,
+		PrivateKey:  stringValue(data.PrivateKey),
+		Certificate: strings.TrimSpace(data.Certificate),
+	}
+}
+
+// keepCredentialSecrets fills secret fields which are not passed on update with values of the existing credential,
+// fields passed as empty strings stay empty, so the secret is removed
+func keepCredentialSecrets(credential *entity.Credential, existing entity.Credential, data CredentialData) {
+	if data.Passphrase == nil {
+		credential.Passphrase = existing.Passphrase
+	}
+
+	if data.Password == nil {
+		credential.Password = existing.Password
+	}
+
+	if data.PrivateKey == nil {
+		credential.PrivateKey = existing.PrivateKey
+	}
+}
+
+// stringValue returns the string or empty string when the pointer is nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func createCredentialResponseFromEntity(c entity.Credential) *CredentialResponse {
 	response := &CredentialResponse{
-		Id:        credential.Id,
-		Name:      credential.Name,
-		Path:      string(credential.Path),
-		CreatedAt: credential.CreatedAt,
-		UpdatedAt: credential.UpdatedAt,
+		Id:          c.Id,
+		Name:        c.Name,
+		Kind:        string(c.Kind),
+		Path:        string(c.Path),
+		AgentSocket: c.AgentSocket,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+
+	if warning := credentialWarning(c); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+
+	if cert, err := parseCertificate(c.Certificate); err == nil {
+		response.Certificate = createCertificateResponse(cert)
+	}
+
+	return response
+}
+
+// credentialWarning returns warning about certificate of the credential, empty string means there is nothing to report
+func credentialWarning(c entity.Credential) string {
+	if c.Certificate == "" {
+		return ""
+	}
+
+	cert, err := parseCertificate(c.Certificate)
+
+	if err != nil {
+		return err.Error()
 	}
 
-	return response, nil
+	return certificateWarning(cert, time.Now())
 }
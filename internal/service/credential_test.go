@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestCredentialServiceUpdateSecrets(t *testing.T) {
+	key := entity.Credential{Id: 1, Name: "deploy", Kind: entity.CredentialKindKey, Path: "/keys/deploy", Passphrase: "phrase", CreatedAt: "2026-10-18T00:00:00Z"}
+	password := entity.Credential{Id: 1, Name: "deploy", Kind: entity.CredentialKindPassword, Password: "pass", CreatedAt: "2026-10-18T00:00:00Z"}
+	empty, changed := "", "changed"
+
+	tests := []struct {
+		name     string
+		existing entity.Credential
+		data     CredentialData
+		want     entity.Credential
+	}{
+		{
+			name:     "omitted passphrase is kept",
+			existing: key,
+			data:     CredentialData{Name: "deploy", Kind: "key", Path: "/keys/deploy"},
+			want:     entity.Credential{Passphrase: "phrase"},
+		},
+		{
+			name:     "empty passphrase is cleared",
+			existing: key,
+			data:     CredentialData{Name: "deploy", Kind: "key", Path: "/keys/deploy", Passphrase: &empty},
+			want:     entity.Credential{},
+		},
+		{
+			name:     "omitted password is kept",
+			existing: password,
+			data:     CredentialData{Name: "deploy", Kind: "password"},
+			want:     entity.Credential{Password: "pass"},
+		},
+		{
+			name:     "passed password replaces the previous one",
+			existing: password,
+			data:     CredentialData{Name: "deploy", Kind: "password", Password: &changed},
+			want:     entity.Credential{Password: "changed"},
+		},
+		{
+			name:     "secrets are not kept when kind is changed",
+			existing: password,
+			data:     CredentialData{Name: "deploy", Kind: "key", Path: "/keys/deploy"},
+			want:     entity.Credential{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryCredentialStorage{credentials: map[int]entity.Credential{1: tt.existing}}
+			s := NewCredentialService(storage, &memoryServerStorage{servers: map[int]entity.Server{}}, &recordingConnections{}, nil, validator.New())
+
+			if _, err := s.Update(context.Background(), 1, tt.data); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			got := storage.credentials[1]
+
+			if got.Passphrase != tt.want.Passphrase || got.Password != tt.want.Password || got.PrivateKey != tt.want.PrivateKey {
+				t.Errorf("Update() secrets = %q %q %q, want %q %q %q", got.Passphrase, got.Password, got.PrivateKey, tt.want.Passphrase, tt.want.Password, tt.want.PrivateKey)
+			}
+		})
+	}
+}
+
+func TestCredentialServiceValidate(t *testing.T) {
+	privateKey, _, err := generateKeyPair(KeyAlgorithmEd25519)
+
+	if err != nil {
+		t.Fatalf("generateKeyPair() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		credential entity.Credential
+		wantErr    bool
+	}{
+		{name: "key file", credential: entity.Credential{Kind: entity.CredentialKindKey, Path: "/keys/deploy"}},
+		{name: "key file with passphrase", credential: entity.Credential{Kind: entity.CredentialKindKey, Path: "/keys/deploy", Passphrase: "phrase"}},
+		{name: "key file without path", credential: entity.Credential{Kind: entity.CredentialKindKey}, wantErr: true},
+		{name: "key file with password", credential: entity.Credential{Kind: entity.CredentialKindKey, Path: "/keys/deploy", Password: "pass"}, wantErr: true},
+		{name: "password", credential: entity.Credential{Kind: entity.CredentialKindPassword, Password: "pass"}},
+		{name: "password without password", credential: entity.Credential{Kind: entity.CredentialKindPassword}, wantErr: true},
+		{name: "password with passphrase", credential: entity.Credential{Kind: entity.CredentialKindPassword, Password: "pass", Passphrase: "phrase"}, wantErr: true},
+		{name: "allowed agent socket", credential: entity.Credential{Kind: entity.CredentialKindAgent, AgentSocket: "/run/agent.sock"}},
+		{name: "not allowed agent socket", credential: entity.Credential{Kind: entity.CredentialKindAgent, AgentSocket: "/run/other/agent.sock"}, wantErr: true},
+		{name: "agent without socket", credential: entity.Credential{Kind: entity.CredentialKindAgent}, wantErr: true},
+		{name: "inline key", credential: entity.Credential{Kind: entity.CredentialKindInlineKey, PrivateKey: privateKey}},
+		{name: "inline key is not a key", credential: entity.Credential{Kind: entity.CredentialKindInlineKey, PrivateKey: "key"}, wantErr: true},
+		{name: "inline key with path", credential: entity.Credential{Kind: entity.CredentialKindInlineKey, PrivateKey: privateKey, Path: "/keys/deploy"}, wantErr: true},
+		{name: "unknown kind", credential: entity.Credential{Kind: "token"}, wantErr: true},
+	}
+
+	s := NewCredentialService(nil, nil, nil, []string{"/run/agent.sock"}, validator.New())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential := tt.credential
+			credential.Name = "deploy"
+			credential.CreatedAt, credential.UpdatedAt = "2026-10-18T00:00:00Z", "2026-10-18T00:00:00Z"
+
+			if err := s.validate(&credential); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgentSocketAllowed(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/ssh-logman/agent.1")
+
+	tests := []struct {
+		name         string
+		agentSockets []string
+		socket       string
+		want         bool
+	}{
+		{name: "configured socket", agentSockets: []string{"/run/a.sock", "/run/b.sock"}, socket: "/run/b.sock", want: true},
+		{name: "configured socket in another form", agentSockets: []string{"/run/a.sock"}, socket: "/run//x/../a.sock", want: true},
+		{name: "not configured socket", agentSockets: []string{"/run/a.sock"}, socket: "/run/c.sock"},
+		{name: "own agent is not allowed when sockets are configured", agentSockets: []string{"/run/a.sock"}, socket: "/tmp/ssh-logman/agent.1"},
+		{name: "own agent by default", socket: "/tmp/ssh-logman/agent.1", want: true},
+		{name: "another agent by default", socket: "/tmp/ssh-other/agent.2"},
+		{name: "empty socket", socket: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := agentSocketAllowed(tt.agentSockets, tt.socket); got != tt.want {
+				t.Errorf("agentSocketAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func (m *memoryCredentialStorage) DeleteById(_ context.Context, id int) error {
+	delete(m.credentials, id)
+
+	return nil
+}
+
+func (m *memoryCredentialStorage) ReassignAndDeleteById(_ context.Context, id, _ int) error {
+	delete(m.credentials, id)
+
+	return nil
+}
+
+func TestCredentialServiceDeleteById(t *testing.T) {
+	tests := []struct {
+		name            string
+		id              int
+		reassignTo      int
+		wantErr         error
+		wantDeleted     bool
+		wantInvalidated []int
+	}{
+		{name: "unused credential", id: 2, wantDeleted: true},
+		{name: "used credential", id: 1, wantErr: ErrCredentialInUse{Servers: []ServerReference{{Id: 10, Name: "web"}}}},
+		{name: "reassigned credential", id: 1, reassignTo: 2, wantDeleted: true, wantInvalidated: []int{10}},
+		{name: "reassigned to itself", id: 1, reassignTo: 1, wantErr: ErrValidation{}},
+		{name: "reassigned to missing credential", id: 1, reassignTo: 7, wantErr: ErrValidation{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryCredentialStorage{credentials: map[int]entity.Credential{
+				1: {Id: 1, Name: "deploy"},
+				2: {Id: 2, Name: "spare"},
+			}}
+			servers := &memoryServerStorage{servers: map[int]entity.Server{10: {Id: 10, Name: "web", CredentialId: 1}}}
+			connections := &recordingConnections{}
+
+			err := NewCredentialService(storage, servers, connections, nil, validator.New()).DeleteById(context.Background(), tt.id, tt.reassignTo)
+
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("DeleteById() error = %v", err)
+				}
+			case ErrValidation:
+				if !errors.As(err, &want) {
+					t.Fatalf("DeleteById() error = %v, want validation error", err)
+				}
+			default:
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Fatalf("DeleteById() error = %#v, want %#v", err, tt.wantErr)
+				}
+			}
+
+			if _, exists := storage.credentials[tt.id]; exists == tt.wantDeleted {
+				t.Errorf("DeleteById() deleted credential = %v, want %v", !exists, tt.wantDeleted)
+			}
+
+			if !reflect.DeepEqual(connections.invalidated, tt.wantInvalidated) {
+				t.Errorf("DeleteById() invalidated servers %v, want %v", connections.invalidated, tt.wantInvalidated)
+			}
+		})
+	}
+}
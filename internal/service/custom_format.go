@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const maxCustomFormatTestLines = 100
+
+// ErrCustomFormatInUse is returned when deleted CustomFormat is the format of log locations
+var ErrCustomFormatInUse = errors.New("custom format is used by log locations")
+
+type CustomFormatStorager interface {
+	Create(ctx context.Context, format *entity.CustomFormat) error
+	GetById(ctx context.Context, id int) (*entity.CustomFormat, error)
+	GetList(ctx context.Context, page, limit int) ([]entity.CustomFormat, error)
+	Update(ctx context.Context, format *entity.CustomFormat) error
+	DeleteById(ctx context.Context, id int) error
+}
+
+type CustomFormatData struct {
+	Name            string            `json:"name"`
+	Pattern         string            `json:"pattern"`
+	TimestampField  string            `json:"timestampField"`
+	TimestampLayout string            `json:"timestampLayout"`
+	LevelField      string            `json:"levelField"`
+	Levels          map[string]string `json:"levels"`
+	MessageField    string            `json:"messageField"`
+}
+
+// A CustomFormatResponse contains fields of the CustomFormat, Format is a value of log location format
+// which references the CustomFormat
+type CustomFormatResponse struct {
+	Id              int               `json:"id"`
+	Name            string            `json:"name"`
+	Format          string            `json:"format"`
+	Pattern         string            `json:"pattern"`
+	TimestampField  string            `json:"timestampField,omitempty"`
+	TimestampLayout string            `json:"timestampLayout,omitempty"`
+	LevelField      string            `json:"levelField,omitempty"`
+	Levels          map[string]string `json:"levels,omitempty"`
+	MessageField    string            `json:"messageField,omitempty"`
+	CreatedAt       string            `json:"createdAt"`
+	UpdatedAt       string            `json:"updatedAt"`
+}
+
+// A CustomFormatTestData contains sample lines parsed by the Format, the Format is ignored
+// when saved CustomFormat is tested
+type CustomFormatTestData struct {
+	Format CustomFormatData `json:"format"`
+	Lines  []string         `json:"lines"`
+}
+
+// A CustomFormatTestResult is a preview of the sample line, Entry is nil when the line is not matched by the Pattern
+type CustomFormatTestResult struct {
+	Line  string    `json:"line"`
+	Entry *LogEntry `json:"entry"`
+}
+
+type CustomFormatService struct {
+	storage         CustomFormatStorager
+	locationStorage LogLocationStorager
+	v               Validator
+}
+
+func NewCustomFormatService(storage CustomFormatStorager, locationStorage LogLocationStorager, v Validator) *CustomFormatService {
+	return &CustomFormatService{
+		storage:         storage,
+		locationStorage: locationStorage,
+		v:               v,
+	}
+}
+
+func (s *CustomFormatService) GetList(ctx context.Context, page, limit int) ([]CustomFormatResponse, error) {
+	formats, err := s.storage.GetList(ctx, page, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during CustomFormat list fetching: %w", err)
+	}
+
+	responses := make([]CustomFormatResponse, len(formats))
+
+	for i, format := range formats {
+		responses[i] = *createCustomFormatResponseFromEntity(format)
+	}
+
+	return responses, nil
+}
+
+// GetById method returns CustomFormat, in case when CustomFormat is not found the method will return nil
+func (s *CustomFormatService) GetById(ctx context.Context, id int) (*CustomFormatResponse, error) {
+	format, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during CustomFormat search by id: %w", err)
+	}
+
+	if format == nil {
+		return nil, nil
+	}
+
+	return createCustomFormatResponseFromEntity(*format), nil
+}
+
+func (s *CustomFormatService) Create(ctx context.Context, data CustomFormatData) (*CustomFormatResponse, error) {
+	now := time.Now()
+
+	format := createCustomFormatEntityFromData(data)
+	format.CreatedAt = now.Format(time.RFC3339)
+	format.UpdatedAt = now.Format(time.RFC3339)
+
+	if err := s.validate(*format); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Create(ctx, format); err != nil {
+		return nil, fmt.Errorf("error during CustomFormat creation: %w", err)
+	}
+
+	return createCustomFormatResponseFromEntity(*format), nil
+}
+
+// Update method replaces fields of the CustomFormat, locations of the format are parsed by the new fields
+// since the next read, in case when CustomFormat is not found the method will return nil
+func (s *CustomFormatService) Update(ctx context.Context, id int, data CustomFormatData) (*CustomFormatResponse, error) {
+	existing, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during CustomFormat search by id: %w", err)
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	format := createCustomFormatEntityFromData(data)
+	format.Id = id
+	format.CreatedAt = existing.CreatedAt
+	format.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := s.validate(*format); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Update(ctx, format); err != nil {
+		return nil, fmt.Errorf("error during CustomFormat update: %w", err)
+	}
+
+	return createCustomFormatResponseFromEntity(*format), nil
+}
+
+// DeleteById method deletes CustomFormat which is not used by log locations
+func (s *CustomFormatService) DeleteById(ctx context.Context, id int) error {
+	locations, err := s.locationStorage.GetListByFormat(ctx, customFormatReference(id))
+
+	if err != nil {
+		return fmt.Errorf("error during LogLocation search by format: %w", err)
+	}
+
+	if len(locations) > 0 {
+		names := make([]string, len(locations))
+
+		for i, location := range locations {
+			names[i] = fmt.Sprintf("%s of server %d", location.Name, location.ServerId)
+		}
+
+		return fmt.Errorf("%w: %s", ErrCustomFormatInUse, strings.Join(names, ", "))
+	}
+
+	if err := s.storage.DeleteById(ctx, id); err != nil {
+		return fmt.Errorf("error during CustomFormat deletion: %w", err)
+	}
+
+	return nil
+}
+
+// Test method parses sample lines by the format which is not saved yet
+func (s *CustomFormatService) Test(ctx context.Context, data CustomFormatTestData) ([]CustomFormatTestResult, error) {
+	format := createCustomFormatEntityFromData(data.Format)
+
+	if err := validateCustomFormat(*format); err != nil {
+		return nil, err
+	}
+
+	return testCustomFormat(*format, data.Lines)
+}
+
+// TestById method parses sample lines by the saved CustomFormat,
+// in case when CustomFormat is not found the method will return nil
+func (s *CustomFormatService) TestById(ctx context.Context, id int, lines []string) ([]CustomFormatTestResult, error) {
+	format, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during CustomFormat search by id: %w", err)
+	}
+
+	if format == nil {
+		return nil, nil
+	}
+
+	return testCustomFormat(*format, lines)
+}
+
+func (s *CustomFormatService) validate(format entity.CustomFormat) error {
+	if err := s.v.Struct(format); err != nil {
+		return buildValidationError(err)
+	}
+
+	return validateCustomFormat(format)
+}
+
+func testCustomFormat(format entity.CustomFormat, lines []string) ([]CustomFormatTestResult, error) {
+	if len(lines) == 0 || len(lines) > maxCustomFormatTestLines {
+		return nil, ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Lines' field, please check the 'Lines' has from 1 to %d lines", maxCustomFormatTestLines)}}
+	}
+
+	parser, err := newCustomLogParser(format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CustomFormatTestResult, len(lines))
+
+	for i, line := range lines {
+		results[i].Line = line
+
+		if entry, err := parser.Parse(line); err == nil {
+			results[i].Entry = &entry
+		}
+	}
+
+	return results, nil
+}
+
+// customFormatReference returns format of log location which references the CustomFormat
+func customFormatReference(id int) entity.LogFormat {
+	return entity.LogFormat(fmt.Sprintf("%s%d", entity.LogLocationFormatCustomPrefix, id))
+}
+
+func createCustomFormatEntityFromData(data CustomFormatData) *entity.CustomFormat {
+	return &entity.CustomFormat{
+		Name:            data.Name,
+		Pattern:         data.Pattern,
+		TimestampField:  data.TimestampField,
+		TimestampLayout: data.TimestampLayout,
+		LevelField:      data.LevelField,
+		Levels:          data.Levels,
+		MessageField:    data.MessageField,
+	}
+}
+
+func createCustomFormatResponseFromEntity(f entity.CustomFormat) *CustomFormatResponse {
+	return &CustomFormatResponse{
+		Id:              f.Id,
+		Name:            f.Name,
+		Format:          string(customFormatReference(f.Id)),
+		Pattern:         f.Pattern,
+		TimestampField:  f.TimestampField,
+		TimestampLayout: f.TimestampLayout,
+		LevelField:      f.LevelField,
+		Levels:          f.Levels,
+		MessageField:    f.MessageField,
+		CreatedAt:       f.CreatedAt,
+		UpdatedAt:       f.UpdatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const (
+	defaultTimestampField = "timestamp"
+	defaultLevelField     = "level"
+	defaultMessageField   = "message"
+)
+
+var errNotCustomFormat = errors.New("line does not match custom format")
+
+// A customLogParser parses lines by the Pattern of CustomFormat, named groups of the Pattern become fields,
+// groups of timestamp, level and message fields default to "timestamp", "level" and "message"
+type customLogParser struct {
+	re     *regexp.Regexp
+	format entity.CustomFormat
+}
+
+func newCustomLogParser(format entity.CustomFormat) (LogParser, error) {
+	re, err := regexp.Compile(format.Pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not compile pattern of custom format %d: %w", format.Id, err)
+	}
+
+	return customLogParser{re: re, format: format}, nil
+}
+
+func (p customLogParser) Parse(line string) (LogEntry, error) {
+	values := p.re.FindStringSubmatch(line)
+
+	if values == nil {
+		return LogEntry{}, errNotCustomFormat
+	}
+
+	entry := LogEntry{Raw: line}
+	fields := map[string]any{}
+
+	for i, name := range p.re.SubexpNames() {
+		if name != "" && values[i] != "" {
+			fields[name] = values[i]
+		}
+	}
+
+	if key := fieldOrDefault(p.format.TimestampField, defaultTimestampField); fields[key] != nil {
+		if t, ok := p.timestamp(fields[key].(string)); ok {
+			entry.Timestamp = &t
+			delete(fields, key)
+		}
+	}
+
+	if key := fieldOrDefault(p.format.LevelField, defaultLevelField); fields[key] != nil {
+		level := fields[key].(string)
+
+		if mapped, ok := p.format.Levels[level]; ok {
+			level = mapped
+		}
+
+		entry.Level = strings.ToLower(level)
+		delete(fields, key)
+	}
+
+	entry.Message = line
+
+	if key := fieldOrDefault(p.format.MessageField, defaultMessageField); fields[key] != nil {
+		entry.Message = fields[key].(string)
+		delete(fields, key)
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+
+	return entry, nil
+}
+
+// timestamp method parses value by TimestampLayout of the format in the time zone of logman,
+// common layouts are tried when the format has no layout
+func (p customLogParser) timestamp(value string) (time.Time, bool) {
+	if p.format.TimestampLayout == "" {
+		return parseTimestamp(value)
+	}
+
+	t, err := time.ParseInLocation(p.format.TimestampLayout, value, time.Local)
+
+	return t, err == nil
+}
+
+// validateCustomFormat checks that Pattern of the format is compiled and its fields are named groups of the Pattern
+func validateCustomFormat(format entity.CustomFormat) error {
+	re, err := regexp.Compile(format.Pattern)
+
+	if err != nil {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Pattern' field, %s", err)}}
+	}
+
+	names := re.SubexpNames()
+
+	if !slices.ContainsFunc(names, func(name string) bool { return name != "" }) {
+		return ErrValidation{Errors: []string{"invalid 'Pattern' field, please check the 'Pattern' has named groups like (?P<message>.*)"}}
+	}
+
+	var errs []string
+
+	for _, field := range []struct{ name, value string }{
+		{"TimestampField", format.TimestampField},
+		{"LevelField", format.LevelField},
+		{"MessageField", format.MessageField},
+	} {
+		if field.value != "" && !slices.Contains(names, field.value) {
+			errs = append(errs, fmt.Sprintf("invalid '%s' field, please check the 'Pattern' has group named %q", field.name, field.value))
+		}
+	}
+
+	if len(errs) > 0 {
+		return ErrValidation{Errors: errs}
+	}
+
+	return nil
+}
+
+func fieldOrDefault(field, defaultField string) string {
+	if field == "" {
+		return defaultField
+	}
+
+	return field
+}
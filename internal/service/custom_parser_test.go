@@ -0,0 +1,118 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestCustomLogParser(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    entity.CustomFormat
+		line      string
+		timestamp *time.Time
+		level     string
+		message   string
+		fields    map[string]any
+		wantErr   bool
+	}{
+		{
+			name: "default fields and layout",
+			format: entity.CustomFormat{
+				Pattern:         `^(?P<timestamp>\S+ \S+) (?P<level>\w) \[(?P<thread>[^\]]+)\] (?P<message>.*)$`,
+				TimestampLayout: "2006-01-02 15:04:05.000",
+				Levels:          map[string]string{"E": "error", "W": "warning"},
+			},
+			line:      "2026-10-18 09:05:12.250 E [worker-1] queue is full",
+			timestamp: timePtr(time.Date(2026, time.October, 18, 9, 5, 12, 250000000, time.Local)),
+			level:     "error",
+			message:   "queue is full",
+			fields:    map[string]any{"thread": "worker-1"},
+		},
+		{
+			name: "renamed fields and common layouts",
+			format: entity.CustomFormat{
+				Pattern:        `^(?P<ts>\S+) (?P<sev>\w+): (?P<text>.*)$`,
+				TimestampField: "ts",
+				LevelField:     "sev",
+				MessageField:   "text",
+			},
+			line:      "2026-10-18T09:05:12Z WARN: disk almost full",
+			timestamp: timePtr(time.Date(2026, time.October, 18, 9, 5, 12, 0, time.UTC)),
+			level:     "warn",
+			message:   "disk almost full",
+		},
+		{
+			name: "unparsed timestamp is kept as field and line is the message",
+			format: entity.CustomFormat{
+				Pattern: `^(?P<timestamp>\S+) (?P<code>\d+)?`,
+			},
+			line:    "yesterday 42 rest",
+			message: "yesterday 42 rest",
+			fields:  map[string]any{"timestamp": "yesterday", "code": "42"},
+		},
+		{
+			name:    "not matched",
+			format:  entity.CustomFormat{Pattern: `^\d+$`},
+			line:    "text",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := newCustomLogParser(tt.format)
+
+			if err != nil {
+				t.Fatalf("newCustomLogParser() error = %v", err)
+			}
+
+			entry, err := parser.Parse(tt.line)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(entry.Timestamp, tt.timestamp) {
+				t.Errorf("Parse() timestamp = %v, want %v", entry.Timestamp, tt.timestamp)
+			}
+
+			if entry.Level != tt.level || entry.Message != tt.message || !reflect.DeepEqual(entry.Fields, tt.fields) {
+				t.Errorf("Parse() = %+v", entry)
+			}
+		})
+	}
+}
+
+func TestValidateCustomFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  entity.CustomFormat
+		wantErr bool
+	}{
+		{name: "valid", format: entity.CustomFormat{Pattern: `(?P<message>.*)`}},
+		{name: "valid renamed field", format: entity.CustomFormat{Pattern: `(?P<text>.*)`, MessageField: "text"}},
+		{name: "invalid regexp", format: entity.CustomFormat{Pattern: `(?P<message>`}, wantErr: true},
+		{name: "no named groups", format: entity.CustomFormat{Pattern: `(.*)`}, wantErr: true},
+		{name: "missing field group", format: entity.CustomFormat{Pattern: `(?P<message>.*)`, LevelField: "severity"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCustomFormat(tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("validateCustomFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const (
+	dockerStreamStdout = "stdout"
+	dockerStreamStderr = "stderr"
+)
+
+// dockerSearchTail is a number of the latest lines of every container stream which are searched,
+// so searches do not read whole logs of long-lived containers
+const dockerSearchTail = 100000
+
+// dockerListCommand builds "docker ps" call which prints names of running containers of the docker location
+func dockerListCommand(location entity.LogLocation) string {
+	args := []string{"docker", "ps", "--format", shellQuote("{{.Names}}")}
+
+	if location.ContainerName != "" {
+		args = append(args, "--filter", shellQuote("name="+location.ContainerName))
+	}
+
+	if location.ContainerLabel != "" {
+		args = append(args, "--filter", shellQuote("label="+location.ContainerLabel))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// dockerLogsCommand builds "docker logs" call which prints only the stream of the container, so stdout and stderr are read apart,
+// the latest limit lines are read when nothing is searched, otherwise the latest dockerSearchTail lines are searched. Errors of docker cli share stderr with the container,
+// so when "docker logs" fails the container is inspected to report the error and the command fails with the same status
+func dockerLogsCommand(container, stream string, matcher *logMatcher) string {
+	args := []string{"docker", "logs", "--timestamps"}
+
+	if matcher.since != nil {
+		args = append(args, "--since", matcher.since.UTC().Format(time.RFC3339))
+	}
+
+	if matcher.until != nil {
+		args = append(args, "--until", matcher.until.Add(time.Second).UTC().Format(time.RFC3339))
+	}
+
+	tail := dockerSearchTail
+
+	if matcher.search == "" && matcher.re == nil {
+		tail = matcher.limit
+	}
+
+	args = append(args, "--tail", fmt.Sprint(tail))
+
+	args = append(args, shellQuote(container))
+
+	redirect := " 2>/dev/null"
+
+	if stream == dockerStreamStderr {
+		redirect = " 2>&1 >/dev/null"
+	}
+
+	return strings.Join(args, " ") + redirect + fmt.Sprintf(
+		` || { status=$?; docker inspect --format '{{.Id}}' %s >/dev/null && echo "docker logs exited with status $status" >&2; exit $status; }`,
+		shellQuote(container),
+	)
+}
+
+// listContainers returns sorted names of containers matched by filters of the location
+func listContainers(ctx context.Context, runner CommandRunner, location entity.LogLocation) ([]string, error) {
+	rc, err := runner.Run(ctx, dockerListCommand(location))
+
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := io.ReadAll(rc)
+
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can not list containers: %w", err)
+	}
+
+	var containers []string
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			containers = append(containers, line)
+		}
+	}
+
+	return containers, nil
+}
+
+// readDocker returns the latest entries of every container of the docker location matched by the matcher
+func readDocker(ctx context.Context, reader LogReader, location entity.LogLocation, parser LogParser, matcher *logMatcher) ([]LogEntry, error) {
+	runner, ok := reader.(CommandRunner)
+
+	if !ok {
+		return nil, fmt.Errorf("containers can not be read over %s", reader.Transport())
+	}
+
+	containers, err := listContainers(ctx, runner, location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+
+	for _, container := range containers {
+		for _, stream := range []string{dockerStreamStdout, dockerStreamStderr} {
+			rc, err := runner.Run(ctx, dockerLogsCommand(container, stream, matcher))
+
+			if err != nil {
+				return nil, err
+			}
+
+			streamEntries, err := scanLogEntries(rc, container, dockerLogParser{parser: parser, container: container, stream: stream}, matcher)
+
+			if closeErr := rc.Close(); err == nil {
+				err = closeErr
+			}
+
+			// lines of the failed command may be errors of docker cli, so they are never returned as entries
+			if err != nil {
+				return nil, fmt.Errorf("can not read %s of container %s: %w", stream, container, err)
+			}
+
+			entries = append(entries, streamEntries...)
+		}
+	}
+
+	return entries, nil
+}
+
+// A dockerLogParser parses lines printed by "docker logs --timestamps", the rest of the line after the timestamp
+// is parsed by the parser of the location Format, lines which can not be parsed are kept as plain messages
+type dockerLogParser struct {
+	parser    LogParser
+	container string
+	stream    string
+}
+
+func (p dockerLogParser) Parse(line string) (LogEntry, error) {
+	raw := line
+
+	var timestamp *time.Time
+
+	if prefix, rest, ok := strings.Cut(line, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+			timestamp = &t
+			line = rest
+		}
+	}
+
+	entry, err := p.parser.Parse(line)
+
+	if err != nil {
+		entry = LogEntry{Message: line}
+	}
+
+	if entry.Timestamp == nil {
+		entry.Timestamp = timestamp
+	}
+
+	if entry.Fields == nil {
+		entry.Fields = map[string]any{}
+	}
+
+	entry.Fields["stream"] = p.stream
+	entry.Source = p.container
+	entry.Raw = raw
+
+	return entry, nil
+}
+
+// checkDocker lists containers, so missing docker cli or lack of permissions are detected
+func checkDocker(ctx context.Context, reader LogReader, location entity.LogLocation) (string, error) {
+	runner, ok := reader.(CommandRunner)
+
+	if !ok {
+		return "", fmt.Errorf("containers can not be read over %s", reader.Transport())
+	}
+
+	containers, err := listContainers(ctx, runner, location)
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d containers found over %s", len(containers), reader.Transport()), nil
+}
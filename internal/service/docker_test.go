@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// fakeDocker is a docker cli which serves the only container web, when DOCKER_FAIL is set docker logs and docker inspect
+// fail like the daemon socket is not accessible
+const fakeDocker = `#!/bin/sh
+case "$1" in
+ps)
+	echo web
+	;;
+logs)
+	if [ -n "$DOCKER_FAIL" ]; then
+		echo "Error response from daemon: permission denied" >&2
+		exit 1
+	fi
+	echo "2026-10-18T10:00:00.000000000Z started"
+	echo "2026-10-18T10:00:01.000000000Z failed" >&2
+	;;
+inspect)
+	if [ -n "$DOCKER_FAIL" ]; then
+		echo "permission denied while trying to connect to the Docker daemon socket" >&2
+		exit 1
+	fi
+	echo 0123456789ab
+	;;
+esac
+`
+
+func TestReadDocker(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(fakeDocker), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	location := entity.LogLocation{Kind: entity.LogLocationKindDocker, Format: entity.LogLocationFormatLogfmt}
+	matcher := &logMatcher{limit: 10}
+
+	entries, err := readDocker(context.Background(), newLocalLogReader(nil), location, logfmtLogParser{}, matcher)
+
+	if err != nil {
+		t.Fatalf("readDocker() error = %v", err)
+	}
+
+	streams := map[string]string{}
+
+	for _, entry := range entries {
+		streams[entry.Fields["stream"].(string)] = entry.Message
+	}
+
+	if len(entries) != 2 || streams[dockerStreamStdout] != "started" || streams[dockerStreamStderr] != "failed" {
+		t.Errorf("readDocker() entries = %+v", entries)
+	}
+
+	t.Setenv("DOCKER_FAIL", "1")
+
+	entries, err = readDocker(context.Background(), newLocalLogReader(nil), location, logfmtLogParser{}, matcher)
+
+	if err == nil || !strings.Contains(err.Error(), "permission denied while trying to connect") {
+		t.Errorf("readDocker() error = %v, entries = %+v, want docker cli error", err, entries)
+	}
+}
+
+func TestDockerLogsCommand(t *testing.T) {
+	since := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		stream  string
+		matcher *logMatcher
+		want    string
+	}{
+		{
+			name:    "latest lines of stdout",
+			stream:  dockerStreamStdout,
+			matcher: &logMatcher{limit: 50},
+			want:    "docker logs --timestamps --tail 50 'web' 2>/dev/null",
+		},
+		{
+			name:    "search of stderr is bounded",
+			stream:  dockerStreamStderr,
+			matcher: &logMatcher{limit: 50, search: "error"},
+			want:    "docker logs --timestamps --tail 100000 'web' 2>&1 >/dev/null",
+		},
+		{
+			name:    "regular expression search since time",
+			stream:  dockerStreamStdout,
+			matcher: &logMatcher{limit: 50, re: regexp.MustCompile("err"), since: &since},
+			want:    "docker logs --timestamps --since 2026-10-18T09:00:00Z --tail 100000 'web' 2>/dev/null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dockerLogsCommand("web", tt.stream, tt.matcher)
+
+			if !strings.HasPrefix(got, tt.want+" || ") {
+				t.Errorf("dockerLogsCommand() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// ErrPrivilegeDenied is returned when the Server reads logs through sudo, but sudo refuses to run commands
+var ErrPrivilegeDenied = errors.New("sudo is not allowed")
+
+// A sudoPrivilege makes remote commands run through sudo, empty password means sudo must not ask for it
+type sudoPrivilege struct {
+	password string
+}
+
+// An execLogReader is a LogReader which executes find and cat commands over ssh,
+// commands are run through sudo when sudo is not nil
+type execLogReader struct {
+	client *sessionClient
+	sudo   *sudoPrivilege
+}
+
+func newExecLogReader(client *sessionClient, sudo *sudoPrivilege) *execLogReader {
+	return &execLogReader{
+		client: client,
+		sudo:   sudo,
+	}
+}
+
+// serverPrivilege returns sudo privilege of the Server, the password of password Credential is used as sudo password
+func serverPrivilege(ctx context.Context, credentials CredentialStorager, server entity.Server) (*sudoPrivilege, error) {
+	if !server.UseSudo {
+		return nil, nil
+	}
+
+	credential, err := credentials.GetById(ctx, server.CredentialId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Credential search by id: %w", err)
+	}
+
+	if credential == nil || credential.Kind != entity.CredentialKindPassword {
+		return &sudoPrivilege{}, nil
+	}
+
+	return &sudoPrivilege{password: credential.Password}, nil
+}
+
+// Glob method returns sorted paths of regular files matched by the pattern, files are searched by find
+// limited to the depth of the pattern, so wildcards do not match nested directories
+func (r *execLogReader) Glob(ctx context.Context, pattern string) ([]string, error) {
+	base, depth := globBase(pattern)
+
+	out, err := r.output(ctx, fmt.Sprintf("find %s -mindepth %d -maxdepth %d -path %s -type f", shellQuote(base), depth, depth, shellQuote(pattern)))
+
+	if err != nil {
+		return nil, fmt.Errorf("can not list files matched by %s: %w", pattern, err)
+	}
+
+	var files []string
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Open method streams content of the file, the returned reader must be closed
+func (r *execLogReader) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return r.stream(ctx, fmt.Sprintf("cat -- %s", shellQuote(path)))
+}
+
+// Filters method reports whether files with the compression can be filtered on the Server,
+// only gzip is decompressed remotely, because its tools are installed everywhere
+func (r *execLogReader) Filters(compression string) bool {
+	return compression == "" || compression == "gz"
+}
+
+// OpenFiltered method streams lines of the file matched by grep, the size of the file on disk is printed by wc before them,
+// exit status 1 of grep means that no lines are matched and is not a failure
+func (r *execLogReader) OpenFiltered(ctx context.Context, path, compression string, filter LineFilter) (io.ReadCloser, int64, error) {
+	rc, err := r.stream(ctx, filterCommand(path, compression, filter))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	br := bufio.NewReader(rc)
+	line, err := br.ReadString('\n')
+
+	if err != nil {
+		if closeErr := rc.Close(); closeErr != nil {
+			err = closeErr
+		}
+
+		return nil, 0, fmt.Errorf("can not read size of %s: %w", path, err)
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+
+	if err != nil {
+		rc.Close()
+		return nil, 0, fmt.Errorf("can not parse size of %s: %w", path, err)
+	}
+
+	return &bufferedReader{Reader: br, Closer: rc}, size, nil
+}
+
+// filterCommand returns shell command which prints the size of the file on disk followed by its lines matched by grep,
+// gzip file is decompressed by gzip before grep. Status of the pipeline is the status of grep and pipefail is not
+// supported by every sh, so the status of gzip is passed through fd 3 and corrupted archive fails the command
+func filterCommand(path, compression string, filter LineFilter) string {
+	grep := "grep -a -E -e " + shellQuote(filter.Pattern)
+
+	if filter.Fixed {
+		grep = "grep -a -F -e " + shellQuote(filter.Pattern)
+	}
+
+	if compression == "gz" {
+		return fmt.Sprintf(
+			`wc -c < %s && { s=$( { { gzip -dc -- %s; echo $? >&3; } | %s >&4; } 3>&1 ); g=$?; test "$s" -eq 0 && test $g -le 1; } 4>&1`,
+			shellQuote(path),
+			shellQuote(path),
+			grep,
+		)
+	}
+
+	return fmt.Sprintf("wc -c < %s && { %s < %s || test $? -eq 1; }", shellQuote(path), grep, shellQuote(path))
+}
+
+// Run method starts the cmd in new ssh session and returns its stdout, the cmd is run through sudo when it is enabled
+func (r *execLogReader) Run(ctx context.Context, cmd string) (io.ReadCloser, error) {
+	return r.stream(ctx, cmd)
+}
+
+func (r *execLogReader) Transport() string {
+	return entity.ServerTransportExec
+}
+
+// Close method does nothing, every command is run in own session which is closed with its output
+func (r *execLogReader) Close() error {
+	return nil
+}
+
+func (r *execLogReader) output(ctx context.Context, cmd string) ([]byte, error) {
+	rc, err := r.stream(ctx, cmd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := io.ReadAll(rc)
+
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+
+	return out, err
+}
+
+// stream method starts the cmd in new ssh session and returns its stdout
+func (r *execLogReader) stream(ctx context.Context, cmd string) (io.ReadCloser, error) {
+	session, release, err := r.client.NewSession(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not open ssh session: %w", err)
+	}
+
+	stdout, err := session.StdoutPipe()
+
+	if err != nil {
+		session.Close()
+		release()
+		return nil, fmt.Errorf("can not attach to stdout: %w", err)
+	}
+
+	s := &sessionReader{session: session, release: release, stdout: stdout, sudo: r.sudo != nil, done: make(chan struct{})}
+	session.Stderr = &s.stderr
+
+	if r.sudo != nil && r.sudo.password != "" {
+		session.Stdin = strings.NewReader(r.sudo.password + "\n")
+	}
+
+	if err := session.Start(r.command(cmd)); err != nil {
+		session.Close()
+		release()
+		return nil, fmt.Errorf("can not start remote command: %w", err)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Close()
+		case <-s.done:
+		}
+	}()
+
+	return s, nil
+}
+
+// command method wraps the cmd into sudo call, stdin of the cmd is detached, so sudo password is never passed to it
+func (r *execLogReader) command(cmd string) string {
+	if r.sudo == nil {
+		return cmd
+	}
+
+	wrapped := shellQuote("exec </dev/null; " + cmd)
+
+	if r.sudo.password == "" {
+		return "sudo -n -- sh -c " + wrapped
+	}
+
+	return "sudo -S -p '' -- sh -c " + wrapped
+}
+
+// A bufferedReader reads buffered stdout of the remote command, closing it closes the command
+type bufferedReader struct {
+	io.Reader
+	io.Closer
+}
+
+// A sessionReader is a stdout of the remote command, closing it waits for the command and reports its failure,
+// the session slot is released after the session is closed
+type sessionReader struct {
+	session *ssh.Session
+	release func()
+	stdout  io.Reader
+	stderr  bytes.Buffer
+	sudo    bool
+	done    chan struct{}
+	eof     bool
+}
+
+func (s *sessionReader) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+
+	if err == io.EOF {
+		s.eof = true
+	}
+
+	return n, err
+}
+
+func (s *sessionReader) Close() error {
+	defer s.release()
+	defer close(s.done)
+	defer s.session.Close()
+
+	// the output is not read till the end, the command is interrupted and its exit status does not matter
+	if !s.eof {
+		return nil
+	}
+
+	if err := s.session.Wait(); err != nil {
+		msg := strings.TrimSpace(s.stderr.String())
+
+		if s.sudo && sudoFailed(msg) {
+			return fmt.Errorf("%w: %s", ErrPrivilegeDenied, msg)
+		}
+
+		if msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// sudoFailed reports whether stderr of the command is produced by sudo itself, not by the command run through it
+func sudoFailed(stderr string) bool {
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "sudo:") || line == "Sorry, try again." || strings.HasSuffix(line, "sudo: not found") || strings.HasSuffix(line, "sudo: command not found") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shellQuote wraps s into single quotes, so it is passed to remote shell as a single literal argument
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
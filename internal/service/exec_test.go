@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestFilterCommand(t *testing.T) {
+	content := "info started\nerror failed\ninfo done\nerror it's broken\n"
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "app's.log")
+
+	if err := os.WriteFile(plain, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	compressed := filepath.Join(dir, "app's.log.1.gz")
+	f, err := os.Create(compressed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte(content))
+	zw.Close()
+	f.Close()
+
+	stat, err := os.Stat(compressed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		compression string
+		filter      LineFilter
+		want        string
+	}{
+		{name: "fixed", path: plain, filter: LineFilter{Pattern: "error", Fixed: true}, want: "LEN\nerror failed\nerror it's broken\n"},
+		{name: "extended", path: plain, filter: LineFilter{Pattern: "^info (.+)e$"}, want: "LEN\ninfo done\n"},
+		{name: "quote", path: plain, filter: LineFilter{Pattern: "it's", Fixed: true}, want: "LEN\nerror it's broken\n"},
+		{name: "no matches", path: plain, filter: LineFilter{Pattern: "debug", Fixed: true}, want: "LEN\n"},
+		{name: "gzip", path: compressed, compression: "gz", filter: LineFilter{Pattern: "error", Fixed: true}, want: "SIZE\nerror failed\nerror it's broken\n"},
+		{name: "gzip no matches", path: compressed, compression: "gz", filter: LineFilter{Pattern: "debug", Fixed: true}, want: "SIZE\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := exec.Command("sh", "-c", filterCommand(tt.path, tt.compression, tt.filter)).CombinedOutput()
+
+			if err != nil {
+				t.Fatalf("command failed: %v: %s", err, out)
+			}
+
+			want := strings.Replace(tt.want, "SIZE", strconv.FormatInt(stat.Size(), 10), 1)
+			want = strings.Replace(want, "LEN", strconv.Itoa(len(content)), 1)
+
+			if got := strings.TrimLeft(string(out), " "); got != want {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFilterCommandFailures(t *testing.T) {
+	dir := t.TempDir()
+
+	var archive bytes.Buffer
+
+	zw := gzip.NewWriter(&archive)
+	zw.Write([]byte(strings.Repeat("info started\n", 1000)))
+	zw.Close()
+
+	truncated := filepath.Join(dir, "truncated.log.1.gz")
+
+	if err := os.WriteFile(truncated, archive.Bytes()[:archive.Len()/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	notArchive := filepath.Join(dir, "plain.log.1.gz")
+
+	if err := os.WriteFile(notArchive, []byte("info started\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		compression string
+	}{
+		{name: "missing file", path: filepath.Join(dir, "missing.log"), compression: ""},
+		{name: "missing gzip file", path: filepath.Join(dir, "missing.log.gz"), compression: "gz"},
+		{name: "truncated gzip without matches", path: truncated, compression: "gz"},
+		{name: "not gzip file without matches", path: notArchive, compression: "gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := filterCommand(tt.path, tt.compression, LineFilter{Pattern: "error", Fixed: true})
+
+			if out, err := exec.Command("sh", "-c", cmd).CombinedOutput(); err == nil {
+				t.Errorf("command succeeded: %s", out)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []string{
+		"/var/log/app.log",
+		"/var/log/app's.log",
+		"/var/log/$(reboot)`id`;|&.log",
+		`/var/log/"quoted" \ back.log`,
+		"",
+	}
+
+	for _, s := range tests {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(s)).Output()
+
+		if err != nil {
+			t.Fatalf("sh -c printf %s error = %v", shellQuote(s), err)
+		}
+
+		if string(out) != s {
+			t.Errorf("shellQuote(%q) is passed to shell as %q", s, out)
+		}
+	}
+}
+
+func TestExecLogReaderCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		sudo *sudoPrivilege
+		want string
+	}{
+		{name: "without sudo", want: `cat -- '/var/log/auth.log'`},
+		{name: "sudo without password", sudo: &sudoPrivilege{}, want: `sudo -n -- sh -c 'exec </dev/null; cat -- '\''/var/log/auth.log'\'''`},
+		{name: "sudo with password", sudo: &sudoPrivilege{password: "secret"}, want: `sudo -S -p '' -- sh -c 'exec </dev/null; cat -- '\''/var/log/auth.log'\'''`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newExecLogReader(nil, tt.sudo).command(`cat -- '/var/log/auth.log'`); got != tt.want {
+				t.Errorf("command() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSudoFailed(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   bool
+	}{
+		{stderr: "sudo: a password is required", want: true},
+		{stderr: "Sorry, try again.\nsudo: 3 incorrect password attempts", want: true},
+		{stderr: "sh: 1: sudo: not found", want: true},
+		{stderr: "bash: sudo: command not found", want: true},
+		{stderr: "cat: /var/log/auth.log: Permission denied"},
+		{stderr: "grep: pseudo: No such file or directory"},
+		{stderr: ""},
+	}
+
+	for _, tt := range tests {
+		if got := sudoFailed(tt.stderr); got != tt.want {
+			t.Errorf("sudoFailed(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestServerPrivilege(t *testing.T) {
+	credentials := &memoryCredentialStorage{credentials: map[int]entity.Credential{
+		1: {Id: 1, Kind: entity.CredentialKindPassword, Password: "secret"},
+		2: {Id: 2, Kind: entity.CredentialKindKey, Path: "/keys/deploy", Passphrase: "phrase"},
+	}}
+
+	tests := []struct {
+		name   string
+		server entity.Server
+		want   *sudoPrivilege
+	}{
+		{name: "sudo disabled", server: entity.Server{CredentialId: 1}},
+		{name: "password is sudo password", server: entity.Server{UseSudo: true, CredentialId: 1}, want: &sudoPrivilege{password: "secret"}},
+		{name: "key credential has no sudo password", server: entity.Server{UseSudo: true, CredentialId: 2}, want: &sudoPrivilege{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := serverPrivilege(context.Background(), credentials, tt.server)
+
+			if err != nil {
+				t.Fatalf("serverPrivilege() error = %v", err)
+			}
+
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("serverPrivilege() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"io"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// A LineFilter is a prefilter pushed to the Server, it matches a superset of lines matched by the query,
+// so the full matcher is still applied to the transferred lines
+type LineFilter struct {
+	// Pattern is a fixed string or POSIX extended regular expression
+	Pattern string
+	// Fixed shows that Pattern is a fixed string
+	Fixed bool
+}
+
+// A FilteringReader is a LogReader which is able to filter lines of the file on the Server
+type FilteringReader interface {
+	// Filters method reports whether files with the compression can be filtered on the Server
+	Filters(compression string) bool
+	// OpenFiltered method returns lines of the file matched by the filter and the size of the file on disk
+	OpenFiltered(ctx context.Context, path, compression string, filter LineFilter) (io.ReadCloser, int64, error)
+}
+
+// A countingReader counts bytes read from the reader into every counter
+type countingReader struct {
+	io.ReadCloser
+	counters []*int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+
+	for _, counter := range c.counters {
+		*counter += int64(n)
+	}
+
+	return n, err
+}
+
+// lineFilter method returns prefilter of the query, regular expressions which can not be expressed
+// in POSIX syntax are reduced to their literal prefix, nil means lines can not be filtered
+func (m *logMatcher) lineFilter() *LineFilter {
+	if m.search == "" {
+		return nil
+	}
+
+	if m.re == nil {
+		return &LineFilter{Pattern: m.search, Fixed: true}
+	}
+
+	if pattern, ok := posixPattern(m.re.String()); ok {
+		return &LineFilter{Pattern: pattern}
+	}
+
+	if prefix, _ := m.re.LiteralPrefix(); prefix != "" {
+		return &LineFilter{Pattern: prefix, Fixed: true}
+	}
+
+	return nil
+}
+
+// posixPattern converts Go regular expression to POSIX extended one, which matches the same lines or more,
+// false is returned when the expression uses syntax without POSIX equivalent
+func posixPattern(expr string) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+
+	if !writePosix(&b, re.Simplify()) {
+		return "", false
+	}
+
+	return b.String(), true
+}
+
+func writePosix(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if !writePosixRune(b, r, re.Flags&syntax.FoldCase != 0) {
+				return false
+			}
+		}
+	case syntax.OpCharClass:
+		return writePosixClass(b, re.Rune)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		// a multibyte character is matched by a single dot only in UTF-8 locale, so any number of bytes is allowed
+		b.WriteString("(.+)")
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		b.WriteString("^")
+	case syntax.OpEndLine, syntax.OpEndText:
+		b.WriteString("$")
+	case syntax.OpCapture:
+		b.WriteString("(")
+
+		if !writePosix(b, re.Sub[0]) {
+			return false
+		}
+
+		b.WriteString(")")
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		b.WriteString("(")
+
+		if !writePosix(b, re.Sub[0]) {
+			return false
+		}
+
+		b.WriteString(")")
+		b.WriteString(map[syntax.Op]string{syntax.OpStar: "*", syntax.OpPlus: "+", syntax.OpQuest: "?"}[re.Op])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writePosix(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		b.WriteString("(")
+
+		for i, sub := range re.Sub {
+			if i > 0 {
+				b.WriteString("|")
+			}
+
+			if !writePosix(b, sub) {
+				return false
+			}
+		}
+
+		b.WriteString(")")
+	default:
+		return false
+	}
+
+	return true
+}
+
+// writePosixRune writes escaped ASCII rune or bytes of non ASCII rune, case folded ASCII letters are written as a bracket
+func writePosixRune(b *strings.Builder, r rune, fold bool) bool {
+	if r >= utf8.RuneSelf {
+		if fold {
+			return false
+		}
+
+		b.WriteRune(r)
+
+		return true
+	}
+
+	if fold && r >= 'a' && r <= 'z' || fold && r >= 'A' && r <= 'Z' {
+		b.WriteString("[" + strings.ToLower(string(r)) + strings.ToUpper(string(r)) + "]")
+
+		return true
+	}
+
+	if strings.ContainsRune(`.[]()*+?{}|^$\`, r) {
+		b.WriteByte('\\')
+	}
+
+	b.WriteRune(r)
+
+	return true
+}
+
+// writePosixClass writes bracket expression of ASCII ranges, characters which are special inside brackets
+// are placed where POSIX treats them literally
+func writePosixClass(b *strings.Builder, ranges []rune) bool {
+	var (
+		body                   strings.Builder
+		bracket, caret, hyphen bool
+	)
+
+	if len(ranges) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+
+		if hi >= utf8.RuneSelf {
+			return false
+		}
+
+		for _, special := range []rune{']', '^', '-'} {
+			if lo <= special && special <= hi && lo != hi {
+				return false
+			}
+		}
+
+		switch {
+		case lo == ']' && hi == ']':
+			bracket = true
+		case lo == '^' && hi == '^':
+			caret = true
+		case lo == '-' && hi == '-':
+			hyphen = true
+		case lo == hi:
+			body.WriteRune(lo)
+		default:
+			body.WriteRune(lo)
+			body.WriteByte('-')
+			body.WriteRune(hi)
+		}
+	}
+
+	// an opening bracket followed by colon, dot or equal sign starts a character class name
+	if strings.Contains(body.String(), "[:") || strings.Contains(body.String(), "[.") || strings.Contains(body.String(), "[=") {
+		return false
+	}
+
+	if caret && body.Len() == 0 && !bracket {
+		if hyphen {
+			return false
+		}
+
+		b.WriteString(`\^`)
+
+		return true
+	}
+
+	b.WriteByte('[')
+
+	if bracket {
+		b.WriteByte(']')
+	}
+
+	b.WriteString(body.String())
+
+	if caret {
+		b.WriteByte('^')
+	}
+
+	if hyphen {
+		b.WriteByte('-')
+	}
+
+	b.WriteByte(']')
+
+	return true
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPosixPattern(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		posixOk bool
+	}{
+		{expr: `error`, want: `error`, posixOk: true},
+		{expr: `a.b`, want: `a(.+)b`, posixOk: true},
+		{expr: `^start|end$`, want: `(^start|end$)`, posixOk: true},
+		{expr: `(?i)err`, want: `[eE][rR][rR]`, posixOk: true},
+		{expr: `a+b*c?`, want: `(a)+(b)*(c)?`, posixOk: true},
+		{expr: `1\.2\(3\)`, want: `1\.2\(3\)`, posixOk: true},
+		{expr: `[0-9a-f]`, want: `[0-9a-f]`, posixOk: true},
+		{expr: `\d`, want: `[0-9]`, posixOk: true},
+		{expr: `a{2,3}`, want: `aa(a)?`, posixOk: true},
+		{expr: `\berror\b`, posixOk: false},
+		{expr: `[^a]`, posixOk: false},
+		{expr: `(?i)ошибка`, posixOk: false},
+		{expr: `(`, posixOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := posixPattern(tt.expr)
+
+		if ok != tt.posixOk {
+			t.Errorf("posixPattern(%q) ok = %v, want %v", tt.expr, ok, tt.posixOk)
+			continue
+		}
+
+		if ok && got != tt.want {
+			t.Errorf("posixPattern(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestWritePosixClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []rune
+		want    string
+		posixOk bool
+	}{
+		{name: "range", ranges: []rune{'a', 'z'}, want: `[a-z]`, posixOk: true},
+		{name: "single characters", ranges: []rune{'a', 'a', 'c', 'c'}, want: `[ac]`, posixOk: true},
+		{name: "closing bracket goes first", ranges: []rune{'a', 'a', ']', ']'}, want: `[]a]`, posixOk: true},
+		{name: "hyphen goes last", ranges: []rune{'-', '-', 'a', 'a'}, want: `[a-]`, posixOk: true},
+		{name: "caret is not first", ranges: []rune{'^', '^', 'a', 'a'}, want: `[a^]`, posixOk: true},
+		{name: "lonely caret", ranges: []rune{'^', '^'}, want: `\^`, posixOk: true},
+		{name: "caret and hyphen", ranges: []rune{'-', '-', '^', '^'}, posixOk: false},
+		{name: "range over special character", ranges: []rune{'A', 'z'}, posixOk: false},
+		{name: "non ASCII", ranges: []rune{'a', 'я'}, posixOk: false},
+		{name: "empty", ranges: nil, posixOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+
+			ok := writePosixClass(&b, tt.ranges)
+
+			if ok != tt.posixOk {
+				t.Fatalf("writePosixClass() ok = %v, want %v", ok, tt.posixOk)
+			}
+
+			if ok && b.String() != tt.want {
+				t.Errorf("writePosixClass() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
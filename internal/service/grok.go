@@ -0,0 +1,364 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// maxGrokExpansion limits size of regular expression expanded from grok expression
+const maxGrokExpansion = 1024 * 1024
+
+// grokStandardPatterns is a standard grok pattern set of logstash, lookarounds and atomic groups
+// which are not supported by Go regular expressions are removed from the patterns
+var grokStandardPatterns = mustParseGrokPatterns(`
+USERNAME [a-zA-Z0-9._-]+
+USER %{USERNAME}
+EMAILLOCALPART [a-zA-Z0-9!#$%&'*+\-/=?^_{|}~]+(?:\.[a-zA-Z0-9!#$%&'*+\-/=?^_{|}~]+)*
+EMAILADDRESS %{EMAILLOCALPART}@%{HOSTNAME}
+INT (?:[+-]?(?:[0-9]+))
+BASE10NUM (?:[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+))
+NUMBER (?:%{BASE10NUM})
+BASE16NUM (?:[+-]?(?:0x)?(?:[0-9A-Fa-f]+))
+BASE16FLOAT \b(?:[+-]?(?:0x)?(?:(?:[0-9A-Fa-f]+(?:\.[0-9A-Fa-f]*)?)|(?:\.[0-9A-Fa-f]+)))\b
+POSINT \b(?:[1-9][0-9]*)\b
+NONNEGINT \b(?:[0-9]+)\b
+WORD \b\w+\b
+NOTSPACE \S+
+SPACE \s*
+DATA .*?
+GREEDYDATA .*
+QUOTEDSTRING (?:"(?:\\.|[^\\"])*"|'(?:\\.|[^\\'])*'|` + "`" + `(?:\\.|[^\\` + "`" + `])*` + "`" + `)
+QS %{QUOTEDSTRING}
+UUID [A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}
+URN urn:[0-9A-Za-z][0-9A-Za-z-]{0,31}:(?:%[0-9a-fA-F]{2}|[0-9A-Za-z()+,.:=@;$_!*'/?#-])+
+
+CISCOMAC (?:(?:[A-Fa-f0-9]{4}\.){2}[A-Fa-f0-9]{4})
+WINDOWSMAC (?:(?:[A-Fa-f0-9]{2}-){5}[A-Fa-f0-9]{2})
+COMMONMAC (?:(?:[A-Fa-f0-9]{2}:){5}[A-Fa-f0-9]{2})
+MAC (?:%{CISCOMAC}|%{WINDOWSMAC}|%{COMMONMAC})
+IPV6 ((([0-9A-Fa-f]{1,4}:){7}([0-9A-Fa-f]{1,4}|:))|(([0-9A-Fa-f]{1,4}:){6}(:[0-9A-Fa-f]{1,4}|((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3})|:))|(([0-9A-Fa-f]{1,4}:){5}(((:[0-9A-Fa-f]{1,4}){1,2})|:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3})|:))|(([0-9A-Fa-f]{1,4}:){4}(((:[0-9A-Fa-f]{1,4}){1,3})|((:[0-9A-Fa-f]{1,4})?:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(([0-9A-Fa-f]{1,4}:){3}(((:[0-9A-Fa-f]{1,4}){1,4})|((:[0-9A-Fa-f]{1,4}){0,2}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(([0-9A-Fa-f]{1,4}:){2}(((:[0-9A-Fa-f]{1,4}){1,5})|((:[0-9A-Fa-f]{1,4}){0,3}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(([0-9A-Fa-f]{1,4}:){1}(((:[0-9A-Fa-f]{1,4}){1,6})|((:[0-9A-Fa-f]{1,4}){0,4}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(:(((:[0-9A-Fa-f]{1,4}){1,7})|((:[0-9A-Fa-f]{1,4}){0,5}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:)))(%.+)?
+IPV4 (?:(?:[0-1]?[0-9]{1,2}|2[0-4][0-9]|25[0-5])[.](?:[0-1]?[0-9]{1,2}|2[0-4][0-9]|25[0-5])[.](?:[0-1]?[0-9]{1,2}|2[0-4][0-9]|25[0-5])[.](?:[0-1]?[0-9]{1,2}|2[0-4][0-9]|25[0-5]))
+IP (?:%{IPV6}|%{IPV4})
+HOSTNAME \b(?:[0-9A-Za-z][0-9A-Za-z-]{0,62})(?:\.(?:[0-9A-Za-z][0-9A-Za-z-]{0,62}))*(?:\.?|\b)
+IPORHOST (?:%{IP}|%{HOSTNAME})
+HOSTPORT %{IPORHOST}:%{POSINT}
+
+PATH (?:%{UNIXPATH}|%{WINPATH})
+UNIXPATH (?:/(?:[\w_%!$@:.,+~-]+|\\.)*)+
+TTY (?:/dev/(?:pts|tty(?:[pq])?)(?:\w+)?/?(?:[0-9]+))
+WINPATH (?:[A-Za-z]+:|\\)(?:\\[^\\?*]*)+
+URIPROTO [A-Za-z](?:[A-Za-z0-9+\-.]+)+
+URIHOST %{IPORHOST}(?::%{POSINT})?
+URIPATH (?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+
+URIQUERY [A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*
+URIPARAM \?%{URIQUERY}
+URIPATHPARAM %{URIPATH}(?:%{URIPARAM})?
+URI %{URIPROTO}://(?:%{USER}(?::[^@]*)?@)?(?:%{URIHOST})?(?:%{URIPATH}(?:%{URIPARAM})?)?
+
+MONTH \b(?:[Jj]an(?:uary|uar)?|[Ff]eb(?:ruary|ruar)?|[Mm](?:a|ä)?r(?:ch|z)?|[Aa]pr(?:il)?|[Mm]a(?:y|i)?|[Jj]un(?:e|i)?|[Jj]ul(?:y|i)?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo](?:c|k)?t(?:ober)?|[Nn]ov(?:ember)?|[Dd]e(?:c|z)(?:ember)?)\b
+MONTHNUM (?:0?[1-9]|1[0-2])
+MONTHNUM2 (?:0[1-9]|1[0-2])
+MONTHDAY (?:(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9])
+DAY (?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)
+YEAR (?:\d\d){1,2}
+HOUR (?:2[0123]|[01]?[0-9])
+MINUTE (?:[0-5][0-9])
+SECOND (?:(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?)
+TIME %{HOUR}:%{MINUTE}(?::%{SECOND})
+DATE_US %{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}
+DATE_EU %{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}
+ISO8601_TIMEZONE (?:Z|[+-]%{HOUR}(?::?%{MINUTE}))
+ISO8601_SECOND %{SECOND}
+TIMESTAMP_ISO8601 %{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?
+DATE %{DATE_US}|%{DATE_EU}
+DATESTAMP %{DATE}[- ]%{TIME}
+TZ (?:[APMCE][SD]T|UTC)
+DATESTAMP_RFC822 %{DAY} %{MONTH} %{MONTHDAY} %{YEAR} %{TIME} %{TZ}
+DATESTAMP_RFC2822 %{DAY}, %{MONTHDAY} %{MONTH} %{YEAR} %{TIME} %{ISO8601_TIMEZONE}
+DATESTAMP_OTHER %{DAY} %{MONTH} %{MONTHDAY} %{TIME} %{TZ} %{YEAR}
+DATESTAMP_EVENTLOG %{YEAR}%{MONTHNUM2}%{MONTHDAY}%{HOUR}%{MINUTE}%{SECOND}
+HTTPDATE %{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}
+
+SYSLOGTIMESTAMP %{MONTH} +%{MONTHDAY} %{TIME}
+PROG [\x21-\x5a\x5c\x5e-\x7e]+
+SYSLOGPROG %{PROG:program}(?:\[%{POSINT:pid}\])?
+SYSLOGHOST %{IPORHOST}
+SYSLOGFACILITY <%{NONNEGINT:facility}.%{NONNEGINT:priority}>
+SYSLOGBASE %{SYSLOGTIMESTAMP:timestamp} (?:%{SYSLOGFACILITY} )?%{SYSLOGHOST:logsource} %{SYSLOGPROG}:
+SYSLOGLINE %{SYSLOGBASE} %{GREEDYDATA:message}
+
+HTTPDUSER %{EMAILADDRESS}|%{USER}
+HTTPDERROR_DATE %{DAY} %{MONTH} %{MONTHDAY} %{TIME} %{YEAR}
+COMMONAPACHELOG %{IPORHOST:clientip} %{HTTPDUSER:ident} %{HTTPDUSER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response} (?:%{NUMBER:bytes}|-)
+COMBINEDAPACHELOG %{COMMONAPACHELOG} %{QS:referrer} %{QS:agent}
+HTTPD_COMMONLOG %{COMMONAPACHELOG}
+HTTPD_COMBINEDLOG %{COMBINEDAPACHELOG}
+
+LOGLEVEL (?:[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo?(?:rmation)?|INFO?(?:RMATION)?|[Ww]arn?(?:ing)?|WARN?(?:ING)?|[Ee]rr?(?:or)?|ERR?(?:OR)?|[Cc]rit?(?:ical)?|CRIT?(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?)
+`)
+
+// grokReference matches %{NAME}, %{NAME:field} and %{NAME:field:type} references of grok expression
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::([^:}]+))?\}`)
+
+// grokNamedGroup matches opening of (?<field>...) and (?P<field>...) named groups of grok expression
+var grokNamedGroup = regexp.MustCompile(`\(\?P?<([^>=!][^>]*)>`)
+
+// grokPatternName matches names of grok patterns
+var grokPatternName = regexp.MustCompile(`^\w+$`)
+
+// grokTimestampLayouts are layouts of timestamps captured by standard patterns which are not supported by parseTimestamp
+var grokTimestampLayouts = []string{
+	accessLogTimeLayout,
+	"2006-01-02 15:04:05,999999999",
+	"2006-01-02T15:04:05,999999999Z07:00",
+	time.RFC1123,
+	time.RFC1123Z,
+	time.ANSIC,
+}
+
+var errNotGrok = errors.New("line does not match grok expression")
+
+// A grokLibrary is a set of grok patterns by name, user defined patterns replace standard patterns of the same name
+type grokLibrary map[string]string
+
+func newGrokLibrary(patterns []entity.GrokPattern) grokLibrary {
+	library := grokLibrary{}
+
+	for name, pattern := range grokStandardPatterns {
+		library[name] = pattern
+	}
+
+	for _, pattern := range patterns {
+		library[pattern.Name] = pattern.Pattern
+	}
+
+	return library
+}
+
+// compile method expands pattern references of the grok expression to the regular expression,
+// every named reference and named group is captured to the field of the entry
+func (l grokLibrary) compile(expr string) (grokLogParser, error) {
+	c := grokCompiler{library: l, fields: map[string]grokField{}}
+
+	expanded, err := c.expand(expr, nil)
+
+	if err != nil {
+		return grokLogParser{}, err
+	}
+
+	re, err := regexp.Compile(expanded)
+
+	if err != nil {
+		return grokLogParser{}, fmt.Errorf("can not compile grok expression: %w", err)
+	}
+
+	return grokLogParser{re: re, fields: c.fields, now: time.Now()}, nil
+}
+
+// A grokField is a field of the entry captured by the group, Type is empty, int or float
+type grokField struct {
+	Name string
+	Type string
+}
+
+// A grokCompiler names captured groups by their order, so field names are not limited by syntax of group names
+type grokCompiler struct {
+	library grokLibrary
+	fields  map[string]grokField
+	size    int
+}
+
+func (c *grokCompiler) expand(expr string, stack []string) (string, error) {
+	var (
+		b    strings.Builder
+		last int
+	)
+
+	for _, m := range grokReference.FindAllStringSubmatchIndex(expr, -1) {
+		b.WriteString(c.namedGroups(expr[last:m[0]]))
+
+		name := expr[m[2]:m[3]]
+
+		if slices.Contains(stack, name) {
+			return "", fmt.Errorf("recursive reference to grok pattern %s", name)
+		}
+
+		pattern, ok := c.library[name]
+
+		if !ok {
+			return "", fmt.Errorf("unknown grok pattern %s", name)
+		}
+
+		body, err := c.expand(pattern, append(stack[:len(stack):len(stack)], name))
+
+		if err != nil {
+			return "", err
+		}
+
+		if m[4] == -1 {
+			b.WriteString("(?:" + body + ")")
+		} else {
+			field := grokField{Name: expr[m[4]:m[5]]}
+
+			if m[6] != -1 {
+				field.Type = expr[m[6]:m[7]]
+			}
+
+			if field.Type != "" && field.Type != "int" && field.Type != "float" && field.Type != "string" {
+				return "", fmt.Errorf("unsupported type %s of field %s, please use int or float", field.Type, field.Name)
+			}
+
+			b.WriteString(c.group(field) + body + ")")
+		}
+
+		last = m[1]
+	}
+
+	b.WriteString(c.namedGroups(expr[last:]))
+
+	if c.size += b.Len(); c.size > maxGrokExpansion {
+		return "", errors.New("grok expression is too large")
+	}
+
+	return b.String(), nil
+}
+
+// namedGroups method replaces names of groups in the part of expression without references by generated names
+func (c *grokCompiler) namedGroups(s string) string {
+	return grokNamedGroup.ReplaceAllStringFunc(s, func(group string) string {
+		return c.group(grokField{Name: grokNamedGroup.FindStringSubmatch(group)[1]})
+	})
+}
+
+func (c *grokCompiler) group(field grokField) string {
+	name := fmt.Sprintf("g%d", len(c.fields))
+	c.fields[name] = field
+
+	return "(?P<" + name + ">"
+}
+
+// A grokLogParser parses lines matched by grok expression, well known fields like timestamp, loglevel
+// and message become timestamp, level and message of the entry, the whole line is a message by default
+type grokLogParser struct {
+	re     *regexp.Regexp
+	fields map[string]grokField
+	now    time.Time
+}
+
+func (p grokLogParser) Parse(line string) (LogEntry, error) {
+	values := p.re.FindStringSubmatch(line)
+
+	if values == nil {
+		return LogEntry{}, errNotGrok
+	}
+
+	fields := map[string]any{}
+
+	for i, name := range p.re.SubexpNames() {
+		if field, ok := p.fields[name]; ok && values[i] != "" {
+			fields[field.Name] = grokValue(values[i], field.Type)
+		}
+	}
+
+	entry := newLogEntryFromFields(line, fields)
+
+	if entry.Timestamp == nil && entry.Fields != nil {
+		if key, value, ok := pickField(entry.Fields, timestampKeys); ok {
+			if t, ok := p.timestamp(fmt.Sprint(value)); ok {
+				entry.Timestamp = &t
+				delete(entry.Fields, key)
+			}
+		}
+	}
+
+	if entry.Message == "" {
+		entry.Message = line
+	}
+
+	return entry, nil
+}
+
+// timestamp method parses timestamps of standard patterns, syslog timestamps without year are read
+// in the time zone of logman
+func (p grokLogParser) timestamp(value string) (time.Time, bool) {
+	for _, layout := range grokTimestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	if t, err := time.ParseInLocation(rfc3164TimeLayout, value, time.Local); err == nil {
+		return syslogYear(t, p.now), true
+	}
+
+	return time.Time{}, false
+}
+
+func grokValue(value, typ string) any {
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return int64(f)
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
+
+// parseGrokPatterns parses patterns file of logstash, every line is a name and a pattern separated by whitespace,
+// empty lines and lines started by # are skipped
+func parseGrokPatterns(text string) (map[string]string, error) {
+	patterns := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxGrokExpansion)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		end := strings.IndexAny(line, " \t")
+
+		if end == -1 {
+			return nil, fmt.Errorf("line %d is not a grok pattern definition", n)
+		}
+
+		name, pattern := line[:end], strings.TrimSpace(line[end:])
+
+		if !grokPatternName.MatchString(name) || pattern == "" {
+			return nil, fmt.Errorf("line %d is not a grok pattern definition", n)
+		}
+
+		patterns[name] = pattern
+	}
+
+	return patterns, scanner.Err()
+}
+
+func mustParseGrokPatterns(text string) map[string]string {
+	patterns, err := parseGrokPatterns(text)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return patterns
+}
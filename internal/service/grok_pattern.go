@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// ErrGrokPatternInUse is returned when deleted GrokPattern is referenced by other patterns or grok log locations
+var ErrGrokPatternInUse = errors.New("grok pattern is in use")
+
+type GrokPatternStorager interface {
+	Create(ctx context.Context, pattern *entity.GrokPattern) error
+	GetById(ctx context.Context, id int) (*entity.GrokPattern, error)
+	GetList(ctx context.Context) ([]entity.GrokPattern, error)
+	Update(ctx context.Context, pattern *entity.GrokPattern) error
+	DeleteById(ctx context.Context, id int) error
+	Import(ctx context.Context, patterns []entity.GrokPattern) error
+}
+
+type GrokPatternData struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+type GrokPatternResponse struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Pattern   string `json:"pattern"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// A GrokImportData contains patterns file of logstash with a pattern definition like "NAME regex" per line
+type GrokImportData struct {
+	Patterns string `json:"patterns"`
+}
+
+type GrokPatternService struct {
+	storage         GrokPatternStorager
+	locationStorage LogLocationStorager
+	v               Validator
+}
+
+func NewGrokPatternService(storage GrokPatternStorager, locationStorage LogLocationStorager, v Validator) *GrokPatternService {
+	return &GrokPatternService{
+		storage:         storage,
+		locationStorage: locationStorage,
+		v:               v,
+	}
+}
+
+// GetList method returns all user defined GrokPatterns, standard patterns are not returned
+func (s *GrokPatternService) GetList(ctx context.Context) ([]GrokPatternResponse, error) {
+	patterns, err := s.storage.GetList(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during GrokPattern list fetching: %w", err)
+	}
+
+	responses := make([]GrokPatternResponse, len(patterns))
+
+	for i, pattern := range patterns {
+		responses[i] = *createGrokPatternResponseFromEntity(pattern)
+	}
+
+	return responses, nil
+}
+
+// GetById method returns GrokPattern, in case when GrokPattern is not found the method will return nil
+func (s *GrokPatternService) GetById(ctx context.Context, id int) (*GrokPatternResponse, error) {
+	pattern, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during GrokPattern search by id: %w", err)
+	}
+
+	if pattern == nil {
+		return nil, nil
+	}
+
+	return createGrokPatternResponseFromEntity(*pattern), nil
+}
+
+func (s *GrokPatternService) Create(ctx context.Context, data GrokPatternData) (*GrokPatternResponse, error) {
+	now := time.Now()
+
+	pattern := &entity.GrokPattern{
+		Name:      data.Name,
+		Pattern:   data.Pattern,
+		CreatedAt: now.Format(time.RFC3339),
+		UpdatedAt: now.Format(time.RFC3339),
+	}
+
+	if err := s.validate(ctx, *pattern); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Create(ctx, pattern); err != nil {
+		return nil, fmt.Errorf("error during GrokPattern creation: %w", err)
+	}
+
+	return createGrokPatternResponseFromEntity(*pattern), nil
+}
+
+// Update method replaces name and pattern of the GrokPattern, the change must not break other patterns
+// and grok log locations, in case when GrokPattern is not found the method will return nil
+func (s *GrokPatternService) Update(ctx context.Context, id int, data GrokPatternData) (*GrokPatternResponse, error) {
+	existing, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during GrokPattern search by id: %w", err)
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	pattern := &entity.GrokPattern{
+		Id:        id,
+		Name:      data.Name,
+		Pattern:   data.Pattern,
+		CreatedAt: existing.CreatedAt,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	if err := s.validate(ctx, *pattern); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Update(ctx, pattern); err != nil {
+		return nil, fmt.Errorf("error during GrokPattern update: %w", err)
+	}
+
+	return createGrokPatternResponseFromEntity(*pattern), nil
+}
+
+// DeleteById method deletes GrokPattern which is not referenced by other patterns and grok log locations,
+// references to the standard pattern of the same name are not broken by the deletion
+func (s *GrokPatternService) DeleteById(ctx context.Context, id int) error {
+	patterns, err := s.storage.GetList(ctx)
+
+	if err != nil {
+		return fmt.Errorf("error during GrokPattern list fetching: %w", err)
+	}
+
+	patterns = slices.DeleteFunc(patterns, func(p entity.GrokPattern) bool { return p.Id == id })
+
+	problems, err := s.check(ctx, patterns)
+
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrGrokPatternInUse, strings.Join(problems, "; "))
+	}
+
+	if err := s.storage.DeleteById(ctx, id); err != nil {
+		return fmt.Errorf("error during GrokPattern deletion: %w", err)
+	}
+
+	return nil
+}
+
+// Import method adds patterns of logstash patterns file, existing patterns of the same name are replaced,
+// the method returns imported patterns
+func (s *GrokPatternService) Import(ctx context.Context, data GrokImportData) ([]GrokPatternResponse, error) {
+	imported, err := parseGrokPatterns(data.Patterns)
+
+	if err != nil {
+		return nil, ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Patterns' field, %s", err)}}
+	}
+
+	if len(imported) == 0 {
+		return nil, ErrValidation{Errors: []string{"invalid 'Patterns' field, please check the 'Patterns' has pattern definitions"}}
+	}
+
+	existing, err := s.storage.GetList(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during GrokPattern list fetching: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+
+	var patterns []entity.GrokPattern
+
+	for name, pattern := range imported {
+		patterns = append(patterns, entity.GrokPattern{Name: name, Pattern: pattern, CreatedAt: now, UpdatedAt: now})
+	}
+
+	slices.SortFunc(patterns, func(a, b entity.GrokPattern) int { return strings.Compare(a.Name, b.Name) })
+
+	var errs []string
+
+	for _, pattern := range patterns {
+		if err := s.v.Struct(pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid pattern %s, %s", pattern.Name, buildValidationError(err).Error()))
+		}
+	}
+
+	library := slices.DeleteFunc(existing, func(p entity.GrokPattern) bool { _, ok := imported[p.Name]; return ok })
+
+	problems, err := s.check(ctx, append(library, patterns...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if errs = append(errs, problems...); len(errs) > 0 {
+		return nil, ErrValidation{Errors: errs}
+	}
+
+	if err := s.storage.Import(ctx, patterns); err != nil {
+		return nil, fmt.Errorf("error during GrokPattern import: %w", err)
+	}
+
+	saved, err := s.GetList(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(saved, func(p GrokPatternResponse) bool { _, ok := imported[p.Name]; return !ok }), nil
+}
+
+// validate method checks fields of the pattern, uniqueness of its name and that the pattern
+// together with other user defined patterns does not break grok log locations
+func (s *GrokPatternService) validate(ctx context.Context, pattern entity.GrokPattern) error {
+	if err := s.v.Struct(pattern); err != nil {
+		return buildValidationError(err)
+	}
+
+	if !grokPatternName.MatchString(pattern.Name) {
+		return ErrValidation{Errors: []string{"invalid 'Name' field, please check the 'Name' contains only letters, digits and underscores"}}
+	}
+
+	patterns, err := s.storage.GetList(ctx)
+
+	if err != nil {
+		return fmt.Errorf("error during GrokPattern list fetching: %w", err)
+	}
+
+	for _, p := range patterns {
+		if p.Name == pattern.Name && p.Id != pattern.Id {
+			return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Name' field, pattern %s already exists", pattern.Name)}}
+		}
+	}
+
+	patterns = slices.DeleteFunc(patterns, func(p entity.GrokPattern) bool { return p.Id == pattern.Id })
+
+	problems, err := s.check(ctx, append(patterns, pattern))
+
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return ErrValidation{Errors: problems}
+	}
+
+	return nil
+}
+
+// check method compiles every user defined pattern and expressions of grok log locations by the library
+// of the patterns and returns compilation problems
+func (s *GrokPatternService) check(ctx context.Context, patterns []entity.GrokPattern) ([]string, error) {
+	library := newGrokLibrary(patterns)
+
+	var problems []string
+
+	for _, pattern := range patterns {
+		if _, err := library.compile("%{" + pattern.Name + "}"); err != nil {
+			problems = append(problems, fmt.Sprintf("pattern %s: %s", pattern.Name, err))
+		}
+	}
+
+	locations, err := s.locationStorage.GetListByFormat(ctx, entity.LogLocationFormatGrok)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during LogLocation search by format: %w", err)
+	}
+
+	for _, location := range locations {
+		if _, err := library.compile(location.Pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("location %s of server %d: %s", location.Name, location.ServerId, err))
+		}
+	}
+
+	return problems, nil
+}
+
+func createGrokPatternResponseFromEntity(p entity.GrokPattern) *GrokPatternResponse {
+	return &GrokPatternResponse{
+		Id:        p.Id,
+		Name:      p.Name,
+		Pattern:   p.Pattern,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestGrokLibraryCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []entity.GrokPattern
+		expr     string
+		wantErr  string
+	}{
+		{name: "standard patterns", expr: "%{COMBINEDAPACHELOG}"},
+		{
+			name:     "user pattern",
+			patterns: []entity.GrokPattern{{Name: "ORDER", Pattern: `ord-%{POSINT}`}},
+			expr:     "%{ORDER:order}",
+		},
+		{name: "unknown pattern", expr: "%{MISSING}", wantErr: "unknown grok pattern MISSING"},
+		{
+			name:     "self reference",
+			patterns: []entity.GrokPattern{{Name: "LOOP", Pattern: `a%{LOOP}`}},
+			expr:     "%{LOOP}",
+			wantErr:  "recursive reference to grok pattern LOOP",
+		},
+		{
+			name: "indirect reference",
+			patterns: []entity.GrokPattern{
+				{Name: "PING", Pattern: `%{PONG}`},
+				{Name: "PONG", Pattern: `%{WORD} %{PING}`},
+			},
+			expr:    "%{PING}",
+			wantErr: "recursive reference to grok pattern PING",
+		},
+		{
+			name:     "pattern reused by siblings is not recursion",
+			patterns: []entity.GrokPattern{{Name: "PAIR", Pattern: `%{INT}-%{INT}`}},
+			expr:     "%{PAIR} %{PAIR}",
+		},
+		{name: "unsupported type", expr: "%{INT:n:bool}", wantErr: "unsupported type bool of field n"},
+		{name: "invalid regexp", expr: "(%{WORD}", wantErr: "can not compile grok expression"},
+		{
+			name:     "too large expansion",
+			patterns: doublingGrokPatterns(20),
+			expr:     "%{D20}",
+			wantErr:  "grok expression is too large",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newGrokLibrary(tt.patterns).compile(tt.expr)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("compile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// doublingGrokPatterns returns patterns D1..Dn where every pattern references the previous one twice,
+// so expansion of Dn is 2^n times larger than D0
+func doublingGrokPatterns(n int) []entity.GrokPattern {
+	patterns := []entity.GrokPattern{{Name: "D0", Pattern: "abcd"}}
+
+	for i := 1; i <= n; i++ {
+		prev := patterns[i-1].Name
+		patterns = append(patterns, entity.GrokPattern{Name: "D" + strconv.Itoa(i), Pattern: "%{" + prev + "}%{" + prev + "}"})
+	}
+
+	return patterns
+}
+
+func TestGrokLogParser(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      string
+		line      string
+		timestamp *time.Time
+		level     string
+		message   string
+		fields    map[string]any
+		wantErr   bool
+	}{
+		{
+			name:    "typed fields",
+			expr:    `%{WORD:method} %{NUMBER:status:int} %{NUMBER:bytes:int} %{NUMBER:duration:float} %{NUMBER:raw}`,
+			line:    "GET 200 1500.0 0.25 7",
+			message: "GET 200 1500.0 0.25 7",
+			fields: map[string]any{
+				"method":   "GET",
+				"status":   int64(200),
+				"bytes":    int64(1500),
+				"duration": 0.25,
+				"raw":      "7",
+			},
+		},
+		{
+			name:    "type falls back to string",
+			expr:    `%{NOTSPACE:n:int}`,
+			line:    "n/a",
+			message: "n/a",
+			fields:  map[string]any{"n": "n/a"},
+		},
+		{
+			name:      "well known fields and named groups",
+			expr:      `\[%{HTTPDATE:timestamp}\] %{LOGLEVEL:level} (?<user>\w+) (?P<message>.*)`,
+			line:      "[18/Oct/2026:09:05:12 +0000] WARN alice quota exceeded",
+			timestamp: timePtr(time.Date(2026, time.October, 18, 9, 5, 12, 0, time.FixedZone("", 0))),
+			level:     "warn",
+			message:   "quota exceeded",
+			fields:    map[string]any{"user": "alice"},
+		},
+		{
+			name:    "unnamed references are not captured",
+			expr:    `%{IP} %{GREEDYDATA:rest}`,
+			line:    "10.0.0.1 hello",
+			message: "10.0.0.1 hello",
+			fields:  map[string]any{"rest": "hello"},
+		},
+		{
+			name:    "not matched",
+			expr:    `^%{INT}$`,
+			line:    "text",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := newGrokLibrary(nil).compile(tt.expr)
+
+			if err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+
+			entry, err := parser.Parse(tt.line)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (entry.Timestamp == nil) != (tt.timestamp == nil) || entry.Timestamp != nil && !entry.Timestamp.Equal(*tt.timestamp) {
+				t.Errorf("Parse() timestamp = %v, want %v", entry.Timestamp, tt.timestamp)
+			}
+
+			if entry.Level != tt.level || entry.Message != tt.message || !reflect.DeepEqual(entry.Fields, tt.fields) {
+				t.Errorf("Parse() = %+v", entry)
+			}
+		})
+	}
+}
+
+func TestParseGrokPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "patterns file",
+			text: "# comment\n\nORDER ord-%{POSINT}\nCODE\t  [A-Z]{3} \n",
+			want: map[string]string{"ORDER": "ord-%{POSINT}", "CODE": "[A-Z]{3}"},
+		},
+		{name: "name without pattern", text: "ORDER\n", wantErr: true},
+		{name: "invalid name", text: "ORDER-ID \\d+\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGrokPatterns(tt.text)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGrokPatterns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGrokPatterns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const journalSource = "journald"
+
+// journalLevels maps syslog priorities used by journald to log levels
+var journalLevels = map[string]string{
+	"0": "emerg",
+	"1": "alert",
+	"2": "crit",
+	"3": "error",
+	"4": "warning",
+	"5": "notice",
+	"6": "info",
+	"7": "debug",
+}
+
+// journalCommand builds journalctl call which prints entries of the journal location as json lines,
+// since and until of the matcher are passed to journalctl, the number of entries is limited only when nothing is searched
+func journalCommand(location entity.LogLocation, matcher *logMatcher) string {
+	args := []string{"journalctl", "--output=json", "--no-pager", "--quiet"}
+
+	if location.Unit != "" {
+		args = append(args, "--unit="+shellQuote(location.Unit))
+	}
+
+	if location.Priority != "" {
+		args = append(args, "--priority="+shellQuote(location.Priority))
+	}
+
+	if matcher.since != nil {
+		args = append(args, fmt.Sprintf("--since=@%d", matcher.since.Unix()))
+	}
+
+	if matcher.until != nil {
+		args = append(args, fmt.Sprintf("--until=@%d", matcher.until.Unix()+1))
+	}
+
+	if matcher.search == "" && matcher.re == nil {
+		args = append(args, fmt.Sprintf("--lines=%d", matcher.limit))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// readJournal returns the latest entries of the journal location matched by the matcher
+func readJournal(ctx context.Context, reader LogReader, location entity.LogLocation, matcher *logMatcher) ([]LogEntry, error) {
+	runner, ok := reader.(CommandRunner)
+
+	if !ok {
+		return nil, fmt.Errorf("journal can not be read over %s", reader.Transport())
+	}
+
+	rc, err := runner.Run(ctx, journalCommand(location, matcher))
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := scanLogEntries(rc, journalSource, journalLogParser{}, matcher)
+
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can not read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// A journalLogParser parses entries printed by "journalctl --output=json", trusted fields of journald
+// which start with underscore are kept except the internal ones starting with double underscore
+type journalLogParser struct{}
+
+func (journalLogParser) Parse(line string) (LogEntry, error) {
+	fields := map[string]any{}
+
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return LogEntry{}, fmt.Errorf("line is not a json object: %w", err)
+	}
+
+	entry := LogEntry{Raw: line}
+
+	if v, ok := fields["__REALTIME_TIMESTAMP"].(string); ok {
+		if usec, err := strconv.ParseInt(v, 10, 64); err == nil {
+			t := time.UnixMicro(usec).UTC()
+			entry.Timestamp = &t
+		}
+	}
+
+	if v, ok := fields["PRIORITY"].(string); ok {
+		entry.Level = journalLevels[v]
+		delete(fields, "PRIORITY")
+	}
+
+	entry.Message = journalMessage(fields["MESSAGE"])
+	delete(fields, "MESSAGE")
+
+	if unit, ok := fields["_SYSTEMD_UNIT"].(string); ok {
+		entry.Source = unit
+	} else if identifier, ok := fields["SYSLOG_IDENTIFIER"].(string); ok {
+		entry.Source = identifier
+	}
+
+	for key := range fields {
+		if strings.HasPrefix(key, "__") {
+			delete(fields, key)
+		}
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+
+	return entry, nil
+}
+
+// journalMessage returns message of the journal entry, messages which are not valid utf-8 are printed by journalctl as byte arrays
+func journalMessage(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		b := make([]byte, 0, len(v))
+
+		for _, item := range v {
+			if n, ok := item.(float64); ok && n >= 0 && n <= math.MaxUint8 {
+				b = append(b, byte(n))
+			}
+		}
+
+		return string(b)
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// checkJournal reads the latest journal entry, so missing journalctl or lack of permissions are detected
+func checkJournal(ctx context.Context, reader LogReader, location entity.LogLocation) (string, error) {
+	entries, err := readJournal(ctx, reader, location, &logMatcher{limit: 1})
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d entries read over %s", len(entries), reader.Transport()), nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestJournalCommand(t *testing.T) {
+	since := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		location entity.LogLocation
+		matcher  logMatcher
+		want     string
+	}{
+		{
+			name:    "latest entries",
+			matcher: logMatcher{limit: 50},
+			want:    "journalctl --output=json --no-pager --quiet --lines=50",
+		},
+		{
+			name:     "unit and priority",
+			location: entity.LogLocation{Unit: "nginx.service", Priority: "err"},
+			matcher:  logMatcher{limit: 50},
+			want:     "journalctl --output=json --no-pager --quiet --unit='nginx.service' --priority='err' --lines=50",
+		},
+		{
+			name:    "time range",
+			matcher: logMatcher{limit: 50, since: &since, until: &until},
+			want:    "journalctl --output=json --no-pager --quiet --since=@1792314000 --until=@1792317601 --lines=50",
+		},
+		{
+			name:    "search is not limited",
+			matcher: logMatcher{limit: 50, search: "failed"},
+			want:    "journalctl --output=json --no-pager --quiet",
+		},
+		{
+			name:    "regexp is not limited",
+			matcher: logMatcher{limit: 50, re: regexp.MustCompile("fail(ed)?")},
+			want:    "journalctl --output=json --no-pager --quiet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := journalCommand(tt.location, &tt.matcher); got != tt.want {
+				t.Errorf("journalCommand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalLogParser(t *testing.T) {
+	timestamp := time.Date(2026, time.October, 18, 9, 5, 12, 3000, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+		want LogEntry
+	}{
+		{
+			name: "unit entry",
+			line: `{"__REALTIME_TIMESTAMP":"1792314312000003","__CURSOR":"s=1","PRIORITY":"3","MESSAGE":"upstream timed out","_SYSTEMD_UNIT":"nginx.service","_PID":"42"}`,
+			want: LogEntry{Timestamp: &timestamp, Level: "error", Message: "upstream timed out", Source: "nginx.service", Fields: map[string]any{"_SYSTEMD_UNIT": "nginx.service", "_PID": "42"}},
+		},
+		{
+			name: "syslog identifier",
+			line: `{"PRIORITY":"6","MESSAGE":"session opened","SYSLOG_IDENTIFIER":"sshd"}`,
+			want: LogEntry{Level: "info", Message: "session opened", Source: "sshd", Fields: map[string]any{"SYSLOG_IDENTIFIER": "sshd"}},
+		},
+		{
+			name: "binary message",
+			line: `{"MESSAGE":[104,105,255]}`,
+			want: LogEntry{Message: "hi\xff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := journalLogParser{}.Parse(tt.line)
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			tt.want.Raw = tt.line
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (journalLogParser{}).Parse("-- No entries --"); err == nil {
+		t.Errorf("Parse() of plain line succeeded, want error")
+	}
+}
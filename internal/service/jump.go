@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const maxJumpHops = 8
+
+var (
+	errJumpServerNotFound = errors.New("jump server not found")
+	errJumpChainCycle     = errors.New("jump servers form a cycle")
+	errJumpChainTooLong   = fmt.Errorf("jump chain is longer than %d hops", maxJumpHops)
+	errJumpServerLocal    = errors.New("local server can not be used as jump server")
+)
+
+// resolveJumpChain returns jump servers which must be passed to reach the server,
+// the outermost jump server is the first one
+func resolveJumpChain(ctx context.Context, storage ServerStorager, server entity.Server) ([]entity.Server, error) {
+	var chain []entity.Server
+
+	visited := map[int]bool{server.Id: true}
+
+	for jumpId := server.JumpServerId; jumpId != 0; {
+		if visited[jumpId] {
+			return nil, fmt.Errorf("%w: server with id %d is visited twice", errJumpChainCycle, jumpId)
+		}
+
+		if len(chain) == maxJumpHops {
+			return nil, errJumpChainTooLong
+		}
+
+		visited[jumpId] = true
+
+		jump, err := storage.GetById(ctx, jumpId)
+
+		if err != nil {
+			return nil, fmt.Errorf("error during Server search by id: %w", err)
+		}
+
+		if jump == nil {
+			return nil, fmt.Errorf("%w: server with id %d", errJumpServerNotFound, jumpId)
+		}
+
+		if jump.Kind == entity.ServerKindLocal {
+			return nil, fmt.Errorf("%w: server with id %d", errJumpServerLocal, jumpId)
+		}
+
+		chain = append([]entity.Server{*jump}, chain...)
+		jumpId = jump.JumpServerId
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// KeyAlgorithmEd25519 is a default algorithm of generated key pairs
+	KeyAlgorithmEd25519 = "ed25519"
+	// KeyAlgorithmRSA generates RSA key pairs of rsaKeyBits size for hosts which do not support ed25519
+	KeyAlgorithmRSA = "rsa"
+
+	rsaKeyBits = 4096
+)
+
+// authorizedKeyRestrictions forbid everything except running commands, which is enough for reading logs,
+// port forwarding is kept for keys of jump servers, because connections to the next hop are tunneled through them
+var authorizedKeyRestrictions = []string{"no-pty", "no-port-forwarding", "no-agent-forwarding", "no-X11-forwarding"}
+
+// generateKeyPair returns PEM encoded private key and public key of the algorithm
+func generateKeyPair(algorithm string) (string, ssh.PublicKey, error) {
+	var (
+		block     *pem.Block
+		publicKey any
+	)
+
+	switch algorithm {
+	case KeyAlgorithmEd25519:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+
+		if err != nil {
+			return "", nil, fmt.Errorf("can not generate ed25519 key: %w", err)
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(private)
+
+		if err != nil {
+			return "", nil, fmt.Errorf("can not encode ed25519 key: %w", err)
+		}
+
+		block, publicKey = &pem.Block{Type: "PRIVATE KEY", Bytes: der}, public
+	case KeyAlgorithmRSA:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+
+		if err != nil {
+			return "", nil, fmt.Errorf("can not generate rsa key: %w", err)
+		}
+
+		block, publicKey = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}, &private.PublicKey
+	default:
+		return "", nil, fmt.Errorf("unsupported key algorithm %q", algorithm)
+	}
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("can not encode public key: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(block)), sshPublicKey, nil
+}
+
+// authorizedKey returns authorized_keys line of the public key, options restrict the key when they are not empty
+func authorizedKey(publicKey ssh.PublicKey, comment string, options []string) string {
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+
+	if comment != "" {
+		line += " " + comment
+	}
+
+	if len(options) > 0 {
+		line = strings.Join(options, ",") + " " + line
+	}
+
+	return line
+}
+
+// authorizedKeyOptions returns suggested options of the generated key, from limits source addresses of the connections,
+// sftp only keys are forced to run sftp server instead of any command and jump host keys may forward ports
+func authorizedKeyOptions(from string, sftpOnly, jumpHost bool) []string {
+	var options []string
+
+	if from != "" {
+		options = append(options, fmt.Sprintf("from=%q", from))
+	}
+
+	if sftpOnly {
+		options = append(options, `command="internal-sftp"`)
+	}
+
+	for _, restriction := range authorizedKeyRestrictions {
+		if jumpHost && restriction == "no-port-forwarding" {
+			continue
+		}
+
+		options = append(options, restriction)
+	}
+
+	return options
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		wantType  string
+		wantErr   bool
+	}{
+		{algorithm: KeyAlgorithmEd25519, wantType: ssh.KeyAlgoED25519},
+		{algorithm: "dsa", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			private, public, err := generateKeyPair(tt.algorithm)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("generateKeyPair() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			signer, err := ssh.ParsePrivateKey([]byte(private))
+
+			if err != nil {
+				t.Fatalf("generated private key can not be parsed: %v", err)
+			}
+
+			if public.Type() != tt.wantType || !bytes.Equal(signer.PublicKey().Marshal(), public.Marshal()) {
+				t.Errorf("generateKeyPair() public key %s does not match the private key", public.Type())
+			}
+		})
+	}
+}
+
+func TestAuthorizedKeyOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		sftpOnly bool
+		jumpHost bool
+		want     []string
+	}{
+		{name: "restricted", want: []string{"no-pty", "no-port-forwarding", "no-agent-forwarding", "no-X11-forwarding"}},
+		{name: "source addresses", from: "10.0.0.0/8,192.168.1.5", want: []string{`from="10.0.0.0/8,192.168.1.5"`, "no-pty", "no-port-forwarding", "no-agent-forwarding", "no-X11-forwarding"}},
+		{name: "sftp only", sftpOnly: true, want: []string{`command="internal-sftp"`, "no-pty", "no-port-forwarding", "no-agent-forwarding", "no-X11-forwarding"}},
+		{name: "jump host", jumpHost: true, want: []string{"no-pty", "no-agent-forwarding", "no-X11-forwarding"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authorizedKeyOptions(tt.from, tt.sftpOnly, tt.jumpHost); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("authorizedKeyOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizedKey(t *testing.T) {
+	_, public, err := generateKeyPair(KeyAlgorithmEd25519)
+
+	if err != nil {
+		t.Fatalf("generateKeyPair() error = %v", err)
+	}
+
+	line := authorizedKey(public, "logman@web", authorizedKeyOptions("10.0.0.1", false, false))
+
+	parsed, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+
+	if err != nil {
+		t.Fatalf("authorized key %q can not be parsed: %v", line, err)
+	}
+
+	if !bytes.Equal(parsed.Marshal(), public.Marshal()) || comment != "logman@web" || len(options) != 5 || options[0] != `from="10.0.0.1"` {
+		t.Errorf("authorizedKey() = %q, parsed options %v and comment %q", line, options, comment)
+	}
+}
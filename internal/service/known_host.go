@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// ErrHostKeyMismatch is returned when the host offers a key which differs from the pinned one
+var ErrHostKeyMismatch = errors.New("host key does not match the pinned key")
+
+type KnownHostStorager interface {
+	Save(ctx context.Context, knownHost *entity.KnownHost) error
+	GetByServerId(ctx context.Context, serverId int) (*entity.KnownHost, error)
+	DeleteByServerId(ctx context.Context, serverId int) error
+}
+
+type HostKeyModel struct {
+	KeyType     string `json:"keyType"`
+	Fingerprint string `json:"fingerprint"`
+	PublicKey   string `json:"publicKey"`
+}
+
+type KnownHostResponse struct {
+	ServerId   int           `json:"serverId"`
+	Host       string        `json:"host"`
+	PinnedKey  HostKeyModel  `json:"pinnedKey"`
+	PendingKey *HostKeyModel `json:"pendingKey,omitempty"`
+	CreatedAt  string        `json:"createdAt"`
+	UpdatedAt  string        `json:"updatedAt"`
+}
+
+type KnownHostService struct {
+	storage KnownHostStorager
+}
+
+func NewKnownHostService(storage KnownHostStorager) *KnownHostService {
+	return &KnownHostService{
+		storage: storage,
+	}
+}
+
+// GetByServerId method returns the pinned host key of the Server,
+// in case when the key is not pinned yet the method will return nil
+func (k *KnownHostService) GetByServerId(ctx context.Context, serverId int) (*KnownHostResponse, error) {
+	knownHost, err := k.storage.GetByServerId(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during KnownHost search by server id: %w", err)
+	}
+
+	if knownHost == nil {
+		return nil, nil
+	}
+
+	return createKnownHostResponseFromEntity(*knownHost), nil
+}
+
+// Accept method pins the pending key offered by the host instead of the current pinned key,
+// in case when the key is not pinned yet the method will return nil
+func (k *KnownHostService) Accept(ctx context.Context, serverId int) (*KnownHostResponse, error) {
+	knownHost, err := k.storage.GetByServerId(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during KnownHost search by server id: %w", err)
+	}
+
+	if knownHost == nil {
+		return nil, nil
+	}
+
+	if !knownHost.HasPendingKey() {
+		return nil, ErrValidation{Errors: []string{fmt.Sprintf("server with id %d has no pending host key", serverId)}}
+	}
+
+	knownHost.KeyType = knownHost.PendingKeyType
+	knownHost.Fingerprint = knownHost.PendingFingerprint
+	knownHost.PublicKey = knownHost.PendingPublicKey
+	knownHost.PendingKeyType = ""
+	knownHost.PendingFingerprint = ""
+	knownHost.PendingPublicKey = ""
+	knownHost.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := k.storage.Save(ctx, knownHost); err != nil {
+		return nil, fmt.Errorf("error during KnownHost saving: %w", err)
+	}
+
+	return createKnownHostResponseFromEntity(*knownHost), nil
+}
+
+// Reset method removes the pinned key, so the key offered on the next connection will be trusted
+func (k *KnownHostService) Reset(ctx context.Context, serverId int) error {
+	if err := k.storage.DeleteByServerId(ctx, serverId); err != nil {
+		return fmt.Errorf("error during KnownHost deletion: %w", err)
+	}
+
+	return nil
+}
+
+func createKnownHostResponseFromEntity(k entity.KnownHost) *KnownHostResponse {
+	response := &KnownHostResponse{
+		ServerId: k.ServerId,
+		Host:     k.Host,
+		PinnedKey: HostKeyModel{
+			KeyType:     k.KeyType,
+			Fingerprint: k.Fingerprint,
+			PublicKey:   k.PublicKey,
+		},
+		CreatedAt: k.CreatedAt,
+		UpdatedAt: k.UpdatedAt,
+	}
+
+	if k.HasPendingKey() {
+		response.PendingKey = &HostKeyModel{
+			KeyType:     k.PendingKeyType,
+			Fingerprint: k.PendingFingerprint,
+			PublicKey:   k.PendingPublicKey,
+		}
+	}
+
+	return response
+}
+
+// A hostKeyVerifier checks host keys with trust-on-first-use policy: the key offered on the first connection
+// to the Server is pinned, later connections are allowed only with the pinned key. The verifier never replaces
+// the pinned key, the pin is replaced by KnownHostService.Accept or removed when address of the Server changes
+type hostKeyVerifier struct {
+	ctx      context.Context
+	storage  KnownHostStorager
+	serverId int
+	// dryRun disables pinning, keys are only compared with already pinned ones
+	dryRun bool
+
+	// err keeps the verification failure, because ssh handshake does not preserve wrapped errors
+	err error
+}
+
+func newHostKeyVerifier(ctx context.Context, storage KnownHostStorager, serverId int, dryRun bool) *hostKeyVerifier {
+	return &hostKeyVerifier{
+		ctx:      ctx,
+		storage:  storage,
+		serverId: serverId,
+		dryRun:   dryRun,
+	}
+}
+
+func (v *hostKeyVerifier) Verify(hostname string, _ net.Addr, key ssh.PublicKey) error {
+	v.err = v.verify(hostname, key)
+
+	return v.err
+}
+
+func (v *hostKeyVerifier) verify(hostname string, key ssh.PublicKey) error {
+	// not saved Server can not have pinned key, the key is pinned on the first connection after saving
+	if v.serverId == 0 {
+		return nil
+	}
+
+	knownHost, err := v.storage.GetByServerId(v.ctx, v.serverId)
+
+	if err != nil {
+		return fmt.Errorf("can not load pinned host key: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	fingerprint := ssh.FingerprintSHA256(key)
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+
+	if knownHost == nil {
+		if v.dryRun {
+			return nil
+		}
+
+		knownHost = &entity.KnownHost{
+			ServerId:    v.serverId,
+			Host:        hostname,
+			KeyType:     key.Type(),
+			Fingerprint: fingerprint,
+			PublicKey:   publicKey,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		if err := v.storage.Save(v.ctx, knownHost); err != nil {
+			return fmt.Errorf("can not pin host key: %w", err)
+		}
+
+		return nil
+	}
+
+	if knownHost.PublicKey == publicKey {
+		return nil
+	}
+
+	// dry run of the Server update checks the new address, the pinned key of the previous address
+	// is removed only when the update is saved
+	if v.dryRun && knownHost.Host != hostname {
+		return nil
+	}
+
+	if !v.dryRun && knownHost.PendingPublicKey != publicKey {
+		knownHost.PendingKeyType = key.Type()
+		knownHost.PendingFingerprint = fingerprint
+		knownHost.PendingPublicKey = publicKey
+		knownHost.UpdatedAt = now
+
+		if err := v.storage.Save(v.ctx, knownHost); err != nil {
+			return fmt.Errorf("can not save pending host key: %w", err)
+		}
+	}
+
+	return fmt.Errorf("%w: host %s offered %s, pinned %s", ErrHostKeyMismatch, hostname, fingerprint, knownHost.Fingerprint)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// memoryKnownHostStorage keeps known hosts by server id and counts saves
+type memoryKnownHostStorage struct {
+	hosts map[int]entity.KnownHost
+	saves int
+}
+
+func (m *memoryKnownHostStorage) Save(_ context.Context, knownHost *entity.KnownHost) error {
+	m.hosts[knownHost.ServerId] = *knownHost
+	m.saves++
+
+	return nil
+}
+
+func (m *memoryKnownHostStorage) GetByServerId(_ context.Context, serverId int) (*entity.KnownHost, error) {
+	knownHost, ok := m.hosts[serverId]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &knownHost, nil
+}
+
+func (m *memoryKnownHostStorage) DeleteByServerId(_ context.Context, serverId int) error {
+	delete(m.hosts, serverId)
+
+	return nil
+}
+
+func TestHostKeyVerifierVerify(t *testing.T) {
+	pinned, offered := newTestHostKey(t), newTestHostKey(t)
+
+	pin := entity.KnownHost{
+		ServerId:    1,
+		Host:        "web:22",
+		KeyType:     pinned.Type(),
+		Fingerprint: ssh.FingerprintSHA256(pinned),
+		PublicKey:   pinnedPublicKey(pinned),
+	}
+
+	tests := []struct {
+		name        string
+		stored      []entity.KnownHost
+		serverId    int
+		dryRun      bool
+		hostname    string
+		key         ssh.PublicKey
+		wantErr     error
+		wantPinned  string
+		wantPending string
+		wantSaves   int
+	}{
+		{
+			name:       "first connection pins the key",
+			serverId:   1,
+			hostname:   "web:22",
+			key:        offered,
+			wantPinned: pinnedPublicKey(offered),
+			wantSaves:  1,
+		},
+		{
+			name:     "first connection of dry run does not pin",
+			serverId: 1,
+			dryRun:   true,
+			hostname: "web:22",
+			key:      offered,
+		},
+		{
+			name:     "not saved server is not pinned",
+			hostname: "web:22",
+			key:      offered,
+		},
+		{
+			name:       "pinned key matches",
+			stored:     []entity.KnownHost{pin},
+			serverId:   1,
+			hostname:   "web:22",
+			key:        pinned,
+			wantPinned: pin.PublicKey,
+		},
+		{
+			name:        "another key becomes pending",
+			stored:      []entity.KnownHost{pin},
+			serverId:    1,
+			hostname:    "web:22",
+			key:         offered,
+			wantErr:     ErrHostKeyMismatch,
+			wantPinned:  pin.PublicKey,
+			wantPending: pinnedPublicKey(offered),
+			wantSaves:   1,
+		},
+		{
+			name:       "another key of dry run is not saved",
+			stored:     []entity.KnownHost{pin},
+			serverId:   1,
+			dryRun:     true,
+			hostname:   "web:22",
+			key:        offered,
+			wantErr:    ErrHostKeyMismatch,
+			wantPinned: pin.PublicKey,
+		},
+		{
+			name:        "changed address does not replace the pin",
+			stored:      []entity.KnownHost{pin},
+			serverId:    1,
+			hostname:    "web:2222",
+			key:         offered,
+			wantErr:     ErrHostKeyMismatch,
+			wantPinned:  pin.PublicKey,
+			wantPending: pinnedPublicKey(offered),
+			wantSaves:   1,
+		},
+		{
+			name:       "changed address of dry run is checked before the pin is reset",
+			stored:     []entity.KnownHost{pin},
+			serverId:   1,
+			dryRun:     true,
+			hostname:   "web:2222",
+			key:        offered,
+			wantPinned: pin.PublicKey,
+		},
+		{
+			name:       "changed address with the pinned key",
+			stored:     []entity.KnownHost{pin},
+			serverId:   1,
+			hostname:   "web:2222",
+			key:        pinned,
+			wantPinned: pin.PublicKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryKnownHostStorage{hosts: map[int]entity.KnownHost{}}
+
+			for _, knownHost := range tt.stored {
+				storage.hosts[knownHost.ServerId] = knownHost
+			}
+
+			err := newHostKeyVerifier(context.Background(), storage, tt.serverId, tt.dryRun).Verify(tt.hostname, nil, tt.key)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if storage.saves != tt.wantSaves {
+				t.Errorf("Verify() saved %d times, want %d", storage.saves, tt.wantSaves)
+			}
+
+			got := storage.hosts[tt.serverId]
+
+			if got.PublicKey != tt.wantPinned || got.PendingPublicKey != tt.wantPending {
+				t.Errorf("Verify() pinned %q pending %q, want %q and %q", got.PublicKey, got.PendingPublicKey, tt.wantPinned, tt.wantPending)
+			}
+		})
+	}
+}
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatalf("can not generate key: %v", err)
+	}
+
+	key, err := ssh.NewPublicKey(public)
+
+	if err != nil {
+		t.Fatalf("can not convert key: %v", err)
+	}
+
+	return key
+}
+
+func pinnedPublicKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// ErrPathNotAllowed is returned when log location of local Server is outside of allowed root directories
+var ErrPathNotAllowed = errors.New("path is outside of allowed local roots")
+
+// A localLogReader is a LogReader of local Server, files are read from the disk of the host logman runs on,
+// only files inside of the roots are read
+type localLogReader struct {
+	roots []string
+}
+
+func newLocalLogReader(roots []string) *localLogReader {
+	return &localLogReader{roots: roots}
+}
+
+// Glob method returns sorted paths of regular files matched by the pattern, files which are resolved
+// by symlinks outside of the roots are skipped
+func (r *localLogReader) Glob(_ context.Context, pattern string) ([]string, error) {
+	if !insideLocalRoots(pattern, r.roots) {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotAllowed, pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not list files matched by %s: %w", pattern, err)
+	}
+
+	var files []string
+
+	for _, match := range matches {
+		info, err := os.Lstat(match)
+
+		if err != nil {
+			return nil, fmt.Errorf("can not stat %s: %w", match, err)
+		}
+
+		if info.Mode().IsRegular() && r.allowed(match) {
+			files = append(files, match)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Open method returns content of the file, the returned reader must be closed
+func (r *localLogReader) Open(_ context.Context, path string) (io.ReadCloser, error) {
+	if !r.allowed(path) {
+		return nil, fmt.Errorf("%w: %s", ErrPathNotAllowed, path)
+	}
+
+	return os.Open(path)
+}
+
+// allowed method reports whether the file is inside of the roots after its symlinks are resolved
+func (r *localLogReader) allowed(path string) bool {
+	resolved, err := filepath.EvalSymlinks(path)
+
+	if err != nil {
+		return false
+	}
+
+	roots := make([]string, len(r.roots))
+
+	for i, root := range r.roots {
+		if roots[i], err = filepath.EvalSymlinks(root); err != nil {
+			roots[i] = root
+		}
+	}
+
+	return underLocalRoots(filepath.Dir(resolved), roots)
+}
+
+// insideLocalRoots reports whether files matched by the absolute glob pattern without parent directory references
+// are inside of one of the roots
+func insideLocalRoots(pattern string, roots []string) bool {
+	if !path.IsAbs(pattern) || slices.Contains(strings.Split(pattern, "/"), "..") {
+		return false
+	}
+
+	base, _ := globBase(pattern)
+
+	return underLocalRoots(base, roots)
+}
+
+// underLocalRoots reports whether the directory is one of the roots or is nested in one of them
+func underLocalRoots(dir string, roots []string) bool {
+	for _, root := range roots {
+		root = path.Clean(root)
+
+		if root == "/" || dir == root || strings.HasPrefix(dir, root+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run method starts the cmd by local shell and returns its stdout
+func (r *localLogReader) Run(ctx context.Context, cmd string) (io.ReadCloser, error) {
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+
+	stdout, err := c.StdoutPipe()
+
+	if err != nil {
+		return nil, fmt.Errorf("can not attach to stdout: %w", err)
+	}
+
+	p := &processReader{cmd: c, stdout: stdout}
+	c.Stderr = &p.stderr
+
+	if err := c.Start(); err != nil {
+		return nil, fmt.Errorf("can not start command: %w", err)
+	}
+
+	return p, nil
+}
+
+func (r *localLogReader) Transport() string {
+	return entity.ServerKindLocal
+}
+
+func (r *localLogReader) Close() error {
+	return nil
+}
+
+// A processReader is a stdout of the local command, closing it waits for the command and reports its failure
+type processReader struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+	eof    bool
+}
+
+func (p *processReader) Read(b []byte) (int, error) {
+	n, err := p.stdout.Read(b)
+
+	if err == io.EOF {
+		p.eof = true
+	}
+
+	return n, err
+}
+
+func (p *processReader) Close() error {
+	// the output is not read till the end, the command is killed and its exit status does not matter
+	if !p.eof {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+
+		return nil
+	}
+
+	if err := p.cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(p.stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInsideLocalRoots(t *testing.T) {
+	roots := []string{"/var/log", "/srv/app/logs/"}
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{pattern: "/var/log/syslog", want: true},
+		{pattern: "/var/log/nginx/*.log", want: true},
+		{pattern: "/srv/app/logs/*.log", want: true},
+		{pattern: "/var/log", want: false},
+		{pattern: "/var/*/syslog", want: false},
+		{pattern: "/var/logs/syslog", want: false},
+		{pattern: "/var/log/../../etc/passwd", want: false},
+		{pattern: "var/log/syslog", want: false},
+		{pattern: "/etc/passwd", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := insideLocalRoots(tt.pattern, roots); got != tt.want {
+			t.Errorf("insideLocalRoots(%s) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestLocalLogReader(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	for _, file := range []string{filepath.Join(root, "b.log"), filepath.Join(root, "a.log"), filepath.Join(outside, "secret.log")} {
+		if err := os.WriteFile(file, []byte("line\n"), 0o644); err != nil {
+			t.Fatalf("can not write file: %v", err)
+		}
+	}
+
+	if err := os.Symlink(filepath.Join(outside, "secret.log"), filepath.Join(root, "link.log")); err != nil {
+		t.Fatalf("can not create symlink: %v", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "dir.log"), 0o755); err != nil {
+		t.Fatalf("can not create directory: %v", err)
+	}
+
+	reader := newLocalLogReader([]string{root})
+
+	t.Run("glob skips directories and symlinks outside of roots", func(t *testing.T) {
+		files, err := reader.Glob(context.Background(), filepath.Join(root, "*.log"))
+
+		if err != nil {
+			t.Fatalf("Glob() error = %v", err)
+		}
+
+		if want := []string{filepath.Join(root, "a.log"), filepath.Join(root, "b.log")}; !reflect.DeepEqual(files, want) {
+			t.Errorf("Glob() = %v, want %v", files, want)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{name: "file inside of roots", path: filepath.Join(root, "a.log")},
+		{name: "file outside of roots", path: filepath.Join(outside, "secret.log"), wantErr: ErrPathNotAllowed},
+		{name: "symlink outside of roots", path: filepath.Join(root, "link.log"), wantErr: ErrPathNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := reader.Open(context.Background(), tt.path)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil {
+				rc.Close()
+			}
+		})
+	}
+
+	if _, err := reader.Glob(context.Background(), filepath.Join(outside, "*.log")); !errors.Is(err, ErrPathNotAllowed) {
+		t.Errorf("Glob() outside of roots error = %v, want %v", err, ErrPathNotAllowed)
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+type LogLocationStorager interface {
+	Create(ctx context.Context, location *entity.LogLocation) error
+	GetById(ctx context.Context, id int) (*entity.LogLocation, error)
+	GetListByServerId(ctx context.Context, serverId int) ([]entity.LogLocation, error)
+	GetListByFormat(ctx context.Context, format entity.LogFormat) ([]entity.LogLocation, error)
+	Update(ctx context.Context, location *entity.LogLocation, id int) error
+	DeleteById(ctx context.Context, id int) error
+}
+
+// A LogLocationData contains log location fields, empty Kind means file location
+type LogLocationData struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	Path           string `json:"path"`
+	Format         string `json:"format"`
+	Pattern        string `json:"pattern"`
+	Unit           string `json:"unit"`
+	Priority       string `json:"priority"`
+	ContainerName  string `json:"containerName"`
+	ContainerLabel string `json:"containerLabel"`
+}
+
+type LogLocationResponse struct {
+	Id             int    `json:"id"`
+	ServerId       int    `json:"serverId"`
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	Path           string `json:"path,omitempty"`
+	Format         string `json:"format"`
+	Pattern        string `json:"pattern,omitempty"`
+	Unit           string `json:"unit,omitempty"`
+	Priority       string `json:"priority,omitempty"`
+	ContainerName  string `json:"containerName,omitempty"`
+	ContainerLabel string `json:"containerLabel,omitempty"`
+	CreatedAt      string `json:"createdAt"`
+	UpdatedAt      string `json:"updatedAt"`
+}
+
+type LogLocationService struct {
+	storage       LogLocationStorager
+	serverStorage ServerStorager
+	formatStorage CustomFormatStorager
+	grokStorage   GrokPatternStorager
+	localRoots    []string
+	v             Validator
+}
+
+func NewLogLocationService(storage LogLocationStorager, serverStorage ServerStorager, formatStorage CustomFormatStorager, grokStorage GrokPatternStorager, localRoots []string, v Validator) *LogLocationService {
+	return &LogLocationService{
+		storage:       storage,
+		serverStorage: serverStorage,
+		formatStorage: formatStorage,
+		grokStorage:   grokStorage,
+		localRoots:    localRoots,
+		v:             v,
+	}
+}
+
+// GetList method returns all LogLocations of the Server,
+// in case when Server is not found the method will return nil
+func (s *LogLocationService) GetList(ctx context.Context, serverId int) ([]LogLocationResponse, error) {
+	server, err := s.serverStorage.GetById(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Server search by id: %w", err)
+	}
+
+	if server == nil {
+		return nil, nil
+	}
+
+	locations, err := s.storage.GetListByServerId(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during LogLocation search by server id: %w", err)
+	}
+
+	responses := make([]LogLocationResponse, len(locations))
+
+	for i, location := range locations {
+		responses[i] = *createLogLocationResponseFromEntity(location)
+	}
+
+	return responses, nil
+}
+
+// GetById method returns LogLocation of the Server,
+// in case when LogLocation is not found or belongs to another Server the method will return nil
+func (s *LogLocationService) GetById(ctx context.Context, serverId, id int) (*LogLocationResponse, error) {
+	location, err := s.fetch(ctx, serverId, id)
+
+	if err != nil || location == nil {
+		return nil, err
+	}
+
+	return createLogLocationResponseFromEntity(*location), nil
+}
+
+// Create method adds new LogLocation to the Server,
+// in case when Server is not found the method will return nil
+func (s *LogLocationService) Create(ctx context.Context, serverId int, data LogLocationData) (*LogLocationResponse, error) {
+	server, err := s.serverStorage.GetById(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Server search by id: %w", err)
+	}
+
+	if server == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+
+	location := createLogLocationEntityFromData(data)
+	location.ServerId = serverId
+	location.CreatedAt = now.Format(time.RFC3339)
+	location.UpdatedAt = now.Format(time.RFC3339)
+
+	if err := s.validate(ctx, *server, *location); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Create(ctx, location); err != nil {
+		return nil, fmt.Errorf("error during LogLocation creation: %w", err)
+	}
+
+	return createLogLocationResponseFromEntity(*location), nil
+}
+
+// Update method replaces fields of the LogLocation,
+// in case when LogLocation is not found or belongs to another Server the method will return nil
+func (s *LogLocationService) Update(ctx context.Context, serverId, id int, data LogLocationData) (*LogLocationResponse, error) {
+	existing, err := s.fetch(ctx, serverId, id)
+
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	server, err := s.serverStorage.GetById(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Server search by id: %w", err)
+	}
+
+	location := createLogLocationEntityFromData(data)
+	location.Id = id
+	location.ServerId = serverId
+	location.CreatedAt = existing.CreatedAt
+	location.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := s.validate(ctx, *server, *location); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Update(ctx, location, id); err != nil {
+		return nil, fmt.Errorf("error during LogLocation update: %w", err)
+	}
+
+	return createLogLocationResponseFromEntity(*location), nil
+}
+
+// DeleteById method deletes LogLocation of the Server, locations of other servers are not touched
+func (s *LogLocationService) DeleteById(ctx context.Context, serverId, id int) error {
+	location, err := s.fetch(ctx, serverId, id)
+
+	if err != nil || location == nil {
+		return err
+	}
+
+	if err := s.storage.DeleteById(ctx, id); err != nil {
+		return fmt.Errorf("error during LogLocation deletion: %w", err)
+	}
+
+	return nil
+}
+
+// fetch method returns LogLocation if it belongs to the Server
+func (s *LogLocationService) fetch(ctx context.Context, serverId, id int) (*entity.LogLocation, error) {
+	location, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during LogLocation search by id: %w", err)
+	}
+
+	if location == nil || location.ServerId != serverId {
+		return nil, nil
+	}
+
+	return location, nil
+}
+
+// validate method checks fields of the location, that CustomFormat referenced by the location exists,
+// that transport of the Server is able to read it and that files of local Server are inside of allowed roots
+func (s *LogLocationService) validate(ctx context.Context, server entity.Server, location entity.LogLocation) error {
+	if err := s.v.Struct(location); err != nil {
+		return buildValidationError(err)
+	}
+
+	if _, err := path.Match(location.Path, ""); err != nil {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Path' field, %s", err)}}
+	}
+
+	if server.Kind == entity.ServerKindLocal && location.Kind == entity.LogLocationKindFile && !insideLocalRoots(location.Path, s.localRoots) {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Path' field, please check the 'Path' is an absolute path inside of %s", strings.Join(s.localRoots, ", "))}}
+	}
+
+	if err := s.validateFormat(ctx, location); err != nil {
+		return err
+	}
+
+	if server.Transport == entity.ServerTransportSftp && !readableBySftp([]entity.LogLocation{location}) {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Kind' field, %s location can not be read over sftp", location.Kind)}}
+	}
+
+	return nil
+}
+
+// validateFormat method checks that Pattern of the location is compiled by the parser of its Format
+func (s *LogLocationService) validateFormat(ctx context.Context, location entity.LogLocation) error {
+	if strings.HasPrefix(string(location.Format), entity.LogLocationFormatCustomPrefix) {
+		return s.validateCustomFormat(ctx, location)
+	}
+
+	var err error
+
+	if location.Format == entity.LogLocationFormatGrok {
+		patterns, listErr := s.grokStorage.GetList(ctx)
+
+		if listErr != nil {
+			return fmt.Errorf("error during GrokPattern list fetching: %w", listErr)
+		}
+
+		_, err = newGrokLibrary(patterns).compile(location.Pattern)
+	} else {
+		_, err = NewLogParser(location)
+	}
+
+	if err != nil {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Pattern' field, %s", err)}}
+	}
+
+	return nil
+}
+
+// validateCustomFormat method checks that Format of the location references existing CustomFormat
+func (s *LogLocationService) validateCustomFormat(ctx context.Context, location entity.LogLocation) error {
+	id, ok := location.CustomFormatId()
+
+	if !ok {
+		return ErrValidation{Errors: []string{"invalid 'Format' field, please check the 'Format' references custom format by id like custom:1"}}
+	}
+
+	format, err := s.formatStorage.GetById(ctx, id)
+
+	if err != nil {
+		return fmt.Errorf("error during CustomFormat search by id: %w", err)
+	}
+
+	if format == nil {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'Format' field, custom format %d is not found", id)}}
+	}
+
+	return nil
+}
+
+func createLogLocationEntityFromData(data LogLocationData) *entity.LogLocation {
+	kind := data.Kind
+
+	if kind == "" {
+		kind = entity.LogLocationKindFile
+	}
+
+	return &entity.LogLocation{
+		Name:           data.Name,
+		Kind:           kind,
+		Path:           data.Path,
+		Format:         entity.LogFormat(data.Format),
+		Pattern:        data.Pattern,
+		Unit:           data.Unit,
+		Priority:       data.Priority,
+		ContainerName:  data.ContainerName,
+		ContainerLabel: data.ContainerLabel,
+	}
+}
+
+func createLogLocationResponseFromEntity(l entity.LogLocation) *LogLocationResponse {
+	return &LogLocationResponse{
+		Id:             l.Id,
+		ServerId:       l.ServerId,
+		Name:           l.Name,
+		Kind:           l.Kind,
+		Path:           l.Path,
+		Format:         string(l.Format),
+		Pattern:        l.Pattern,
+		Unit:           l.Unit,
+		Priority:       l.Priority,
+		ContainerName:  l.ContainerName,
+		ContainerLabel: l.ContainerLabel,
+		CreatedAt:      l.CreatedAt,
+		UpdatedAt:      l.UpdatedAt,
+	}
+}
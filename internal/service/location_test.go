@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func (m *memoryLocationStorage) Create(_ context.Context, location *entity.LogLocation) error {
+	location.Id = len(m.locations) + 1
+	m.locations = append(m.locations, *location)
+
+	return nil
+}
+
+func (m *memoryLocationStorage) GetById(_ context.Context, id int) (*entity.LogLocation, error) {
+	for _, location := range m.locations {
+		if location.Id == id {
+			return &location, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		wantBase  string
+		wantDepth int
+	}{
+		{pattern: "/var/log/syslog", wantBase: "/var/log", wantDepth: 1},
+		{pattern: "/var/log/*.log", wantBase: "/var/log", wantDepth: 1},
+		{pattern: "/var/log/nginx/*/access.log", wantBase: "/var/log/nginx", wantDepth: 2},
+		{pattern: "/var/log/app-[0-9]/*.log", wantBase: "/var/log", wantDepth: 2},
+		{pattern: "/*.log", wantBase: "/", wantDepth: 1},
+		{pattern: "/var//log/./*.log", wantBase: "/var/log", wantDepth: 1},
+	}
+
+	for _, tt := range tests {
+		base, depth := globBase(tt.pattern)
+
+		if base != tt.wantBase || depth != tt.wantDepth {
+			t.Errorf("globBase(%s) = %s, %d, want %s, %d", tt.pattern, base, depth, tt.wantBase, tt.wantDepth)
+		}
+	}
+}
+
+func TestLogLocationServiceCreate(t *testing.T) {
+	servers := map[int]entity.Server{
+		1: {Id: 1, Kind: entity.ServerKindSsh, Transport: entity.ServerTransportAuto},
+		2: {Id: 2, Kind: entity.ServerKindSsh, Transport: entity.ServerTransportSftp},
+		3: {Id: 3, Kind: entity.ServerKindLocal},
+	}
+
+	tests := []struct {
+		name     string
+		serverId int
+		data     LogLocationData
+		wantErr  bool
+		wantNil  bool
+	}{
+		{name: "glob pattern", serverId: 1, data: LogLocationData{Name: "nginx", Path: "/var/log/nginx/*.log", Format: "json"}},
+		{name: "journal location", serverId: 1, data: LogLocationData{Name: "sshd", Kind: "journald", Unit: "sshd.service", Format: "json"}},
+		{name: "server not found", serverId: 7, data: LogLocationData{Name: "nginx", Path: "/var/log/nginx/*.log", Format: "json"}, wantNil: true},
+		{name: "relative path", serverId: 1, data: LogLocationData{Name: "nginx", Path: "nginx/*.log", Format: "json"}, wantErr: true},
+		{name: "malformed glob pattern", serverId: 1, data: LogLocationData{Name: "nginx", Path: "/var/log/[nginx/*.log", Format: "json"}, wantErr: true},
+		{name: "path of journal location", serverId: 1, data: LogLocationData{Name: "sshd", Kind: "journald", Path: "/var/log/*.log", Format: "json"}, wantErr: true},
+		{name: "journal over sftp", serverId: 2, data: LogLocationData{Name: "sshd", Kind: "journald", Format: "json"}, wantErr: true},
+		{name: "local file inside of roots", serverId: 3, data: LogLocationData{Name: "syslog", Path: "/var/log/syslog", Format: "syslog_rfc3164"}},
+		{name: "local file outside of roots", serverId: 3, data: LogLocationData{Name: "passwd", Path: "/etc/passwd", Format: "json"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locations := &memoryLocationStorage{}
+			s := NewLogLocationService(locations, &memoryServerStorage{servers: servers}, nil, nil, []string{"/var/log"}, validator.New())
+
+			got, err := s.Create(context.Background(), tt.serverId, tt.data)
+
+			var validationErr ErrValidation
+
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("Create() error = %v, want validation error %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("Create() = %+v, want nil %v", got, tt.wantNil)
+			}
+
+			if got != nil && (got.ServerId != tt.serverId || len(locations.locations) != 1) {
+				t.Errorf("Create() = %+v, stored %d locations", got, len(locations.locations))
+			}
+		})
+	}
+}
+
+func TestLogLocationServiceGetByIdOfAnotherServer(t *testing.T) {
+	locations := &memoryLocationStorage{locations: []entity.LogLocation{{Id: 1, ServerId: 1, Name: "nginx"}}}
+	s := NewLogLocationService(locations, &memoryServerStorage{}, nil, nil, nil, validator.New())
+
+	tests := []struct {
+		serverId int
+		wantNil  bool
+	}{
+		{serverId: 1},
+		{serverId: 2, wantNil: true},
+	}
+
+	for _, tt := range tests {
+		got, err := s.GetById(context.Background(), tt.serverId, 1)
+
+		if err != nil {
+			t.Fatalf("GetById() error = %v", err)
+		}
+
+		if (got == nil) != tt.wantNil {
+			t.Errorf("GetById() of server %d = %+v, want nil %v", tt.serverId, got, tt.wantNil)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const (
+	defaultLogLimit = 100
+	maxLogLimit     = 10000
+	maxLogLineSize  = 1024 * 1024
+)
+
+// A LogQuery describes which log entries must be returned
+type LogQuery struct {
+	// Limit is a maximum number of the latest entries in the response
+	Limit int
+	// Search is a substring or regular expression which must be contained in the raw line
+	Search string
+	// Regex shows that Search is a regular expression
+	Regex bool
+	Since *time.Time
+	Until *time.Time
+	// LocationId limits entries to the single LogLocation, zero means all locations of the Server
+	LocationId int
+}
+
+type LogsResponse struct {
+	Entries []LogEntry `json:"entries"`
+	Meta    LogsMeta   `json:"meta"`
+}
+
+// A LogsMeta describes amount of log file data read to build the response
+type LogsMeta struct {
+	// BytesScanned is a size of log files read on the Server, compressed files are counted by their size on disk
+	BytesScanned int64 `json:"bytesScanned"`
+	// BytesTransferred is a size of log file data received from the Server,
+	// it is less than BytesScanned when lines are filtered on the Server
+	BytesTransferred int64 `json:"bytesTransferred"`
+	// Prefiltered shows that lines of some files are filtered on the Server
+	Prefiltered bool `json:"prefiltered"`
+}
+
+type LogService struct {
+	serverStorage     ServerStorager
+	credentialStorage CredentialStorager
+	locationStorage   LogLocationStorager
+	formatStorage     CustomFormatStorager
+	grokStorage       GrokPatternStorager
+	pool              *ConnectionPool
+	// localRoots are directories log locations of local servers are read from
+	localRoots []string
+	l          Logger
+}
+
+func NewLogService(serverStorage ServerStorager, credentialStorage CredentialStorager, locationStorage LogLocationStorager, formatStorage CustomFormatStorager, grokStorage GrokPatternStorager, pool *ConnectionPool, localRoots []string, l Logger) *LogService {
+	return &LogService{
+		serverStorage:     serverStorage,
+		credentialStorage: credentialStorage,
+		locationStorage:   locationStorage,
+		formatStorage:     formatStorage,
+		grokStorage:       grokStorage,
+		pool:              pool,
+		localRoots:        localRoots,
+		l:                 l,
+	}
+}
+
+// FetchByServerId method reads log locations of the Server and returns the latest entries matched to the query,
+// in case when Server is not found the method will return nil
+func (s *LogService) FetchByServerId(ctx context.Context, id int, query LogQuery) (*LogsResponse, error) {
+	matcher, err := newLogMatcher(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := s.serverStorage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Server search by id: %w", err)
+	}
+
+	if server == nil {
+		return nil, nil
+	}
+
+	locations, err := s.locations(ctx, server.Id, query.LocationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(locations) == 0 {
+		return &LogsResponse{Entries: []LogEntry{}}, nil
+	}
+
+	parsers := make([]LogParser, len(locations))
+
+	for i, location := range locations {
+		if parsers[i], err = newLocationParser(ctx, s.formatStorage, s.grokStorage, location); err != nil {
+			return nil, err
+		}
+	}
+
+	reader, release, err := s.openReader(ctx, *server, locations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	var (
+		entries []LogEntry
+		meta    LogsMeta
+	)
+
+	for i, location := range locations {
+		locationEntries, err := readLogLocation(ctx, reader, location, parsers[i], matcher, &meta)
+
+		if err != nil {
+			s.l.Error("can not read log location", slog.Int("locationId", location.Id), slog.String("error", err.Error()))
+			return nil, err
+		}
+
+		entries = append(entries, locationEntries...)
+	}
+
+	return &LogsResponse{Entries: matcher.latest(entries), Meta: meta}, nil
+}
+
+// locations method returns LogLocations of the Server which must be read, zero locationId means all of them
+func (s *LogService) locations(ctx context.Context, serverId, locationId int) ([]entity.LogLocation, error) {
+	locations, err := s.locationStorage.GetListByServerId(ctx, serverId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during LogLocation search by server id: %w", err)
+	}
+
+	if locationId == 0 {
+		return locations, nil
+	}
+
+	for _, location := range locations {
+		if location.Id == locationId {
+			return []entity.LogLocation{location}, nil
+		}
+	}
+
+	return nil, ErrValidation{Errors: []string{fmt.Sprintf("log location with id %d not found", locationId)}}
+}
+
+// openReader method returns LogReader of the Server, remote servers are read over pooled ssh connection,
+// the returned func closes the reader and releases the connection
+func (s *LogService) openReader(ctx context.Context, server entity.Server, locations []entity.LogLocation) (LogReader, func(), error) {
+	if server.Kind == entity.ServerKindLocal {
+		return newLocalLogReader(s.localRoots), func() {}, nil
+	}
+
+	sudo, err := serverPrivilege(ctx, s.credentialStorage, server)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, release, err := s.pool.Acquire(ctx, server)
+
+	if err != nil {
+		s.l.Error("can not connect to server", slog.Int("serverId", server.Id), slog.String("error", err.Error()))
+		return nil, nil, err
+	}
+
+	reader, err := openLogReader(ctx, client, server, locations, sudo, s.l)
+
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	return reader, func() {
+		reader.Close()
+		release()
+	}, nil
+}
+
+// readLogLocation returns the latest entries of the location matched by the matcher,
+// lines of files and containers are parsed by the parser of the location format
+func readLogLocation(ctx context.Context, reader LogReader, location entity.LogLocation, parser LogParser, matcher *logMatcher, meta *LogsMeta) ([]LogEntry, error) {
+	var (
+		entries []LogEntry
+		err     error
+	)
+
+	switch location.Kind {
+	case entity.LogLocationKindJournald:
+		entries, err = readJournal(ctx, reader, location, matcher)
+	case entity.LogLocationKindDocker:
+		entries, err = readDocker(ctx, reader, location, parser, matcher)
+	default:
+		entries, err = readLogFiles(ctx, reader, location, parser, matcher, meta)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Location = location.Name
+	}
+
+	return entries, nil
+}
+
+// readLogFiles returns the latest entries of every file matched by Path of the location,
+// rotated siblings of the file are read before it as a single stream
+func readLogFiles(ctx context.Context, reader LogReader, location entity.LogLocation, parser LogParser, matcher *logMatcher, meta *LogsMeta) ([]LogEntry, error) {
+	groups, err := globRotations(ctx, reader, location.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+
+	for _, group := range groups {
+		groupEntries, err := readRotationGroup(ctx, reader, group, parser, matcher, meta)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, groupEntries...)
+	}
+
+	return entries, nil
+}
+
+// readRotationGroup returns the latest entries of the log file and its rotations matched by the matcher,
+// lines are prefiltered on the Server when the query has search term
+func readRotationGroup(ctx context.Context, reader LogReader, group rotationGroup, parser LogParser, matcher *logMatcher, meta *LogsMeta) ([]LogEntry, error) {
+	rc := newRotationReader(ctx, reader, group, matcher.lineFilter(), meta)
+
+	entries, err := scanLogEntries(rc, group.name, parser, matcher)
+
+	if closeErr := rc.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can not read %s: %w", group.name, err)
+	}
+
+	return entries, nil
+}
+
+// scanLogEntries parses every line of the r, keeping at most limit latest matched entries,
+// lines which can not be parsed are kept as plain messages
+func scanLogEntries(r io.Reader, source string, parser LogParser, matcher *logMatcher) ([]LogEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+
+	var (
+		entries  []LogEntry
+		lastTime time.Time
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" || !matcher.matchLine(line) {
+			continue
+		}
+
+		entry, err := parser.Parse(line)
+
+		if err != nil {
+			entry = LogEntry{Message: line, Raw: line}
+		}
+
+		if entry.Source == "" {
+			entry.Source = source
+		}
+
+		if entry.Timestamp != nil {
+			lastTime = *entry.Timestamp
+		}
+
+		entry.orderTime = lastTime
+
+		if !matcher.matchEntry(entry) {
+			continue
+		}
+
+		entries = append(entries, entry)
+
+		if len(entries) > 2*matcher.limit {
+			entries = append(entries[:0], entries[len(entries)-matcher.limit:]...)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) > matcher.limit {
+		entries = entries[len(entries)-matcher.limit:]
+	}
+
+	return entries, nil
+}
+
+// A logMatcher applies LogQuery conditions to the lines and entries
+type logMatcher struct {
+	limit  int
+	search string
+	re     *regexp.Regexp
+	since  *time.Time
+	until  *time.Time
+}
+
+func newLogMatcher(query LogQuery) (*logMatcher, error) {
+	m := &logMatcher{
+		limit:  query.Limit,
+		search: query.Search,
+		since:  query.Since,
+		until:  query.Until,
+	}
+
+	if m.limit <= 0 {
+		m.limit = defaultLogLimit
+	}
+
+	if m.limit > maxLogLimit {
+		m.limit = maxLogLimit
+	}
+
+	if query.Regex && query.Search != "" {
+		re, err := regexp.Compile(query.Search)
+
+		if err != nil {
+			return nil, ErrValidation{Errors: []string{fmt.Sprintf("invalid 'query' regular expression: %s", err)}}
+		}
+
+		m.re = re
+	}
+
+	return m, nil
+}
+
+func (m *logMatcher) matchLine(line string) bool {
+	if m.re != nil {
+		return m.re.MatchString(line)
+	}
+
+	return strings.Contains(line, m.search)
+}
+
+// matchEntry checks time window of the entry, entries without timestamp are always matched
+func (m *logMatcher) matchEntry(entry LogEntry) bool {
+	if entry.Timestamp == nil {
+		return true
+	}
+
+	if m.since != nil && entry.Timestamp.Before(*m.since) {
+		return false
+	}
+
+	if m.until != nil && entry.Timestamp.After(*m.until) {
+		return false
+	}
+
+	return true
+}
+
+// latest method orders entries by timestamp and returns at most limit latest of them
+func (m *logMatcher) latest(entries []LogEntry) []LogEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].orderTime.Before(entries[j].orderTime)
+	})
+
+	if len(entries) > m.limit {
+		entries = entries[len(entries)-m.limit:]
+	}
+
+	if entries == nil {
+		entries = []LogEntry{}
+	}
+
+	return entries
+}
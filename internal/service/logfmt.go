@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var errNotLogfmt = errors.New("line does not contain key=value pairs")
+
+// A logfmtLogParser parses lines of space separated key=value pairs, values may be double quoted with Go escapes,
+// keys without values are kept as true fields
+type logfmtLogParser struct{}
+
+func (logfmtLogParser) Parse(line string) (LogEntry, error) {
+	fields, err := parseLogfmt(line)
+
+	if err != nil {
+		return LogEntry{}, err
+	}
+
+	entry := newLogEntryFromFields(line, fields)
+
+	// heroku writes level of the line to the "at" key
+	if at, ok := entry.Fields["at"].(string); ok && entry.Level == "" {
+		entry.Level = strings.ToLower(at)
+		delete(entry.Fields, "at")
+	}
+
+	return entry, nil
+}
+
+// parseLogfmt returns fields of the line, the line must have at least one key with value
+func parseLogfmt(line string) (map[string]any, error) {
+	fields := map[string]any{}
+	pairs := 0
+
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+
+		key := line[start:i]
+
+		if key == "" {
+			return nil, fmt.Errorf("unexpected %q at %d", line[i], i)
+		}
+
+		if i == len(line) || line[i] != '=' {
+			fields[key] = true
+			continue
+		}
+
+		i++
+
+		value, n, err := logfmtValue(line[i:])
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", key, err)
+		}
+
+		fields[key] = value
+		i += n
+		pairs++
+	}
+
+	if pairs == 0 {
+		return nil, errNotLogfmt
+	}
+
+	return fields, nil
+}
+
+// logfmtValue returns value at the beginning of s and the number of bytes it takes
+func logfmtValue(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, " \t")
+
+		if end == -1 {
+			end = len(s)
+		}
+
+		return s[:end], end, nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+
+			if err != nil {
+				return "", 0, err
+			}
+
+			return value, i + 1, nil
+		}
+	}
+
+	return "", 0, errors.New("unterminated quoted value")
+}
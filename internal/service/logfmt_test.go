@@ -0,0 +1,96 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "plain values",
+			line: "level=info msg=started port=8080",
+			want: map[string]any{"level": "info", "msg": "started", "port": "8080"},
+		},
+		{
+			name: "quoted values with escapes",
+			line: `msg="user \"bob\" logged in" path="C:\\logs" empty=""`,
+			want: map[string]any{"msg": `user "bob" logged in`, "path": `C:\logs`, "empty": ""},
+		},
+		{
+			name: "keys without values",
+			line: "debug level=warn\tretry",
+			want: map[string]any{"debug": true, "level": "warn", "retry": true},
+		},
+		{
+			name: "value with equal sign",
+			line: "query=a=b",
+			want: map[string]any{"query": "a=b"},
+		},
+		{
+			name:    "no pairs",
+			line:    "plain text line",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			line:    `msg="broken`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid escape",
+			line:    `msg="\q"`,
+			wantErr: true,
+		},
+		{
+			name:    "quote in key",
+			line:    `a=1 "b"=2`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLogfmt(tt.line)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogfmt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLogfmt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogfmtLogParser(t *testing.T) {
+	entry, err := logfmtLogParser{}.Parse(`time=2026-10-18T10:00:00Z level=ERROR msg="disk full" mount=/var`)
+
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if entry.Timestamp == nil || entry.Timestamp.Format("2006-01-02T15:04:05Z07:00") != "2026-10-18T10:00:00Z" {
+		t.Errorf("Parse() timestamp = %v", entry.Timestamp)
+	}
+
+	if entry.Level != "error" || entry.Message != "disk full" || !reflect.DeepEqual(entry.Fields, map[string]any{"mount": "/var"}) {
+		t.Errorf("Parse() = %+v", entry)
+	}
+
+	entry, err = logfmtLogParser{}.Parse("at=info method=GET status=200")
+
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if entry.Level != "info" || !reflect.DeepEqual(entry.Fields, map[string]any{"method": "GET", "status": "200"}) {
+		t.Errorf("Parse() of heroku line = %+v", entry)
+	}
+}
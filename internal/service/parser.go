@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+var (
+	timestampKeys = []string{"time", "timestamp", "ts", "@timestamp", "datetime"}
+	levelKeys     = []string{"level", "lvl", "severity", "loglevel"}
+	messageKeys   = []string{"msg", "message", "@message"}
+)
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// A LogEntry is a single parsed line of a log file
+type LogEntry struct {
+	Timestamp *time.Time     `json:"timestamp,omitempty"`
+	Level     string         `json:"level,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	Source    string         `json:"source"`
+	Location  string         `json:"location,omitempty"`
+	Raw       string         `json:"raw"`
+
+	// orderTime is a timestamp of the entry or of the closest previous entry of the same source,
+	// it is used for ordering entries which do not have own timestamp
+	orderTime time.Time
+}
+
+// A LogParser converts raw log line to the LogEntry
+type LogParser interface {
+	Parse(line string) (LogEntry, error)
+}
+
+// NewLogParser returns LogParser for the LogFormat of the location
+func NewLogParser(location entity.LogLocation) (LogParser, error) {
+	switch location.Format {
+	case entity.LogLocationFormatJson:
+		return jsonLogParser{}, nil
+	case entity.LogLocationFormatLogfmt:
+		return logfmtLogParser{}, nil
+	case entity.LogLocationFormatSyslogRfc3164:
+		return rfc3164LogParser{now: time.Now()}, nil
+	case entity.LogLocationFormatSyslogRfc5424:
+		return rfc5424LogParser{}, nil
+	case entity.LogLocationFormatNginx:
+		return newAccessLogParser(location.Pattern)
+	}
+
+	if pattern, ok := accessLogPatterns[location.Format]; ok {
+		return newAccessLogParser(pattern)
+	}
+
+	return nil, fmt.Errorf("unsupported log format %q", location.Format)
+}
+
+// newLocationParser returns LogParser of the location, CustomFormat referenced by the location
+// and user defined grok patterns are fetched from the storages
+func newLocationParser(ctx context.Context, formatStorage CustomFormatStorager, grokStorage GrokPatternStorager, location entity.LogLocation) (LogParser, error) {
+	if location.Format == entity.LogLocationFormatGrok {
+		return newGrokParser(ctx, grokStorage, location.Pattern)
+	}
+
+	id, ok := location.CustomFormatId()
+
+	if !ok {
+		return NewLogParser(location)
+	}
+
+	format, err := formatStorage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during CustomFormat search by id: %w", err)
+	}
+
+	if format == nil {
+		return nil, fmt.Errorf("custom format %d of location %s is not found", id, location.Name)
+	}
+
+	return newCustomLogParser(*format)
+}
+
+// newGrokParser returns LogParser of the grok expression, the expression may reference user defined patterns
+func newGrokParser(ctx context.Context, grokStorage GrokPatternStorager, expr string) (LogParser, error) {
+	patterns, err := grokStorage.GetList(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during GrokPattern list fetching: %w", err)
+	}
+
+	parser, err := newGrokLibrary(patterns).compile(expr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parser, nil
+}
+
+type jsonLogParser struct{}
+
+func (jsonLogParser) Parse(line string) (LogEntry, error) {
+	fields := map[string]any{}
+
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return LogEntry{}, fmt.Errorf("line is not a json object: %w", err)
+	}
+
+	return newLogEntryFromFields(line, fields), nil
+}
+
+// newLogEntryFromFields extracts well known timestamp, level and message keys from fields,
+// the rest of the fields are kept in the LogEntry.Fields
+func newLogEntryFromFields(line string, fields map[string]any) LogEntry {
+	entry := LogEntry{Raw: line}
+
+	if key, value, ok := pickField(fields, timestampKeys); ok {
+		if t, ok := parseTimestamp(value); ok {
+			entry.Timestamp = &t
+			delete(fields, key)
+		}
+	}
+
+	if key, value, ok := pickField(fields, levelKeys); ok {
+		entry.Level = strings.ToLower(fmt.Sprint(value))
+		delete(fields, key)
+	}
+
+	if key, value, ok := pickField(fields, messageKeys); ok {
+		entry.Message = fmt.Sprint(value)
+		delete(fields, key)
+	}
+
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+
+	return entry
+}
+
+func pickField(fields map[string]any, keys []string) (string, any, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			return key, value, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// parseTimestamp supports string timestamps in common layouts and unix timestamps in seconds or milliseconds
+func parseTimestamp(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	case float64:
+		if v > 1e12 {
+			return time.UnixMilli(int64(v)).UTC(), true
+		}
+
+		sec, frac := math.Modf(v)
+
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,610 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const (
+	healthCheckInterval = 30 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+	maxRetryBackoff     = 30 * time.Second
+)
+
+// A ConnectionManager controls pooled connections of servers
+type ConnectionManager interface {
+	// Invalidate method drops pooled connections of the Server, so the next usage reconnects with actual settings
+	Invalidate(serverId int)
+	// BreakerStatus method returns state of circuit breaker of the Server
+	BreakerStatus(serverId int) BreakerStatus
+}
+
+// A PoolLimits restricts load logman puts on servers, zero limits of sessions mean no limit,
+// every remote command and sftp subsystem is a separate ssh session
+type PoolLimits struct {
+	// MaxSessions limits concurrent ssh sessions over one connection
+	MaxSessions int
+	// MaxHostSessions limits concurrent ssh sessions to one host shared by all its servers
+	MaxHostSessions int
+	// MaxTotalSessions limits concurrent ssh sessions to all servers
+	MaxTotalSessions int
+	// RetryAttempts is a number of retries of connection failed by transient network error
+	RetryAttempts int
+	// RetryBackoff is a delay before the first retry, every next delay is doubled
+	RetryBackoff time.Duration
+	// BreakerThreshold is a number of consecutive connection failures which opens circuit breaker, zero disables it
+	BreakerThreshold int
+	// BreakerCooldown is a period the open circuit breaker rejects connections
+	BreakerCooldown time.Duration
+}
+
+// A PoolStats contains state of a pooled connection
+type PoolStats struct {
+	ServerId       int    `json:"serverId"`
+	Host           string `json:"host"`
+	ActiveSessions int    `json:"activeSessions"`
+	MaxSessions    int    `json:"maxSessions"`
+	Waiting        int    `json:"waiting"`
+	Acquired       int64  `json:"acquired"`
+	ConnectedAt    string `json:"connectedAt"`
+	LastUsedAt     string `json:"lastUsedAt"`
+}
+
+type PoolStatsResponse struct {
+	IdleTimeout      string      `json:"idleTimeout"`
+	MaxSessions      int         `json:"maxSessions"`
+	MaxHostSessions  int         `json:"maxHostSessions"`
+	MaxTotalSessions int         `json:"maxTotalSessions"`
+	Connections      []PoolStats `json:"connections"`
+}
+
+// A ConnectionPool keeps one ssh client per Server and multiplexes sessions over it,
+// connections unused longer than idle timeout are closed
+type ConnectionPool struct {
+	connector   *SSHConnector
+	limits      PoolLimits
+	idleTimeout time.Duration
+	breakers    *circuitBreakers
+	l           Logger
+
+	// total limits sessions to all servers, hosts limit sessions per host, nil channels mean no limit
+	total chan struct{}
+	hosts map[string]chan struct{}
+
+	mu    sync.Mutex
+	conns map[int]*pooledConnection
+}
+
+type pooledConnection struct {
+	serverId int
+	host     string
+	// via contains ids of jump servers the connection is tunneled through
+	via []int
+
+	client *ssh.Client
+	err    error
+	// ready is closed when the dialing is finished, client and err must not be read before
+	ready chan struct{}
+
+	// sessions limits sessions over the connection, nil channel means no limit
+	sessions chan struct{}
+	active   int
+	waiting  int
+	acquired int64
+	// users is a number of acquired clients which are not released yet, the connection is not closed while it is used
+	users         int
+	invalid       bool
+	connectedAt   time.Time
+	lastUsedAt    time.Time
+	lastCheckedAt time.Time
+}
+
+func NewConnectionPool(connector *SSHConnector, limits PoolLimits, idleTimeout time.Duration, l Logger) *ConnectionPool {
+	p := &ConnectionPool{
+		connector:   connector,
+		limits:      limits,
+		idleTimeout: idleTimeout,
+		breakers:    newCircuitBreakers(limits.BreakerThreshold, limits.BreakerCooldown),
+		l:           l,
+		hosts:       map[string]chan struct{}{},
+		conns:       map[int]*pooledConnection{},
+	}
+
+	if limits.MaxTotalSessions > 0 {
+		p.total = make(chan struct{}, limits.MaxTotalSessions)
+	}
+
+	go p.closeIdle()
+
+	return p
+}
+
+// Acquire method returns client of the pooled connection of the Server, every session opened by the client
+// is waited while the limit of sessions of the connection, the host or all servers is reached,
+// the returned func must be called when the client is not used anymore
+func (p *ConnectionPool) Acquire(ctx context.Context, server entity.Server) (*sessionClient, func(), error) {
+	if err := p.breakers.allow(server.Id); err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := p.connectionWithRetries(ctx, server)
+
+	var shared *sharedDialError
+
+	switch {
+	case err == nil:
+		p.breakers.success(server.Id)
+	case ctx.Err() != nil, errors.As(err, &shared), errors.Is(err, context.Canceled):
+		p.breakers.abort(server.Id)
+	default:
+		p.breakers.failure(server.Id)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	conn.users++
+	p.mu.Unlock()
+
+	release := sync.OnceFunc(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		conn.users--
+		conn.lastUsedAt = time.Now()
+
+		if conn.invalid && conn.users == 0 {
+			conn.client.Close()
+		}
+	})
+
+	client := &sessionClient{
+		client: conn.client,
+		acquire: func(ctx context.Context) (func(), error) {
+			return p.acquireSession(ctx, conn)
+		},
+	}
+
+	return client, release, nil
+}
+
+// acquireSession method reserves session of the connection, its host and all servers, the session is waited
+// while any of the limits is reached, the returned func releases the session
+func (p *ConnectionPool) acquireSession(ctx context.Context, conn *pooledConnection) (func(), error) {
+	releaseSlots, err := p.acquireSlots(ctx, conn.host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	conn.waiting++
+	p.mu.Unlock()
+
+	err = acquireSlot(ctx, conn.sessions)
+
+	p.mu.Lock()
+	conn.waiting--
+
+	if err == nil {
+		conn.active++
+		conn.acquired++
+	}
+
+	p.mu.Unlock()
+
+	if err != nil {
+		releaseSlots()
+		return nil, err
+	}
+
+	return sync.OnceFunc(func() {
+		p.mu.Lock()
+		conn.active--
+		conn.lastUsedAt = time.Now()
+		p.mu.Unlock()
+
+		releaseSlot(conn.sessions)
+		releaseSlots()
+	}), nil
+}
+
+// acquireSlots method reserves session of the host and of all servers, the returned func releases both of them
+func (p *ConnectionPool) acquireSlots(ctx context.Context, host string) (func(), error) {
+	p.mu.Lock()
+	hostSlots, ok := p.hosts[host]
+
+	if !ok && p.limits.MaxHostSessions > 0 {
+		hostSlots = make(chan struct{}, p.limits.MaxHostSessions)
+		p.hosts[host] = hostSlots
+	}
+
+	p.mu.Unlock()
+
+	if err := acquireSlot(ctx, hostSlots); err != nil {
+		return nil, err
+	}
+
+	if err := acquireSlot(ctx, p.total); err != nil {
+		releaseSlot(hostSlots)
+		return nil, err
+	}
+
+	return func() {
+		releaseSlot(p.total)
+		releaseSlot(hostSlots)
+	}, nil
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}) error {
+	if slots == nil {
+		return nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
+
+// connectionWithRetries method retries connection failed by transient network error with exponential backoff
+func (p *ConnectionPool) connectionWithRetries(ctx context.Context, server entity.Server) (*pooledConnection, error) {
+	backoff := p.limits.RetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		conn, err := p.connection(ctx, server)
+
+		if err == nil || attempt > p.limits.RetryAttempts || ctx.Err() != nil || !transientError(err) {
+			return conn, err
+		}
+
+		p.l.Info(
+			"connection failed, retrying",
+			slog.Int("serverId", server.Id),
+			slog.Int("attempt", attempt),
+			slog.String("backoff", backoff.String()),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
+
+// transientError reports whether the connection failed by network error which may disappear on the next attempt,
+// authentication and configuration errors are never retried
+func transientError(err error) bool {
+	switch checkCause(err) {
+	case "timeout", "connection_refused", "host_unreachable":
+		return true
+	case "unknown":
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+
+	return false
+}
+
+// BreakerStatus method returns state of circuit breaker of the Server
+func (p *ConnectionPool) BreakerStatus(serverId int) BreakerStatus {
+	return p.breakers.status(serverId)
+}
+
+// Invalidate method drops connections to the Server and connections tunneled through it and resets circuit breaker
+// of the Server, connections which are used are closed after the last client is released
+func (p *ConnectionPool) Invalidate(serverId int) {
+	p.breakers.reset(serverId)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, conn := range p.conns {
+		if id == serverId || slices.Contains(conn.via, serverId) {
+			p.discardLocked(conn)
+		}
+	}
+}
+
+// Stats method returns state of every pooled connection
+func (p *ConnectionPool) Stats() PoolStatsResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]PoolStats, 0, len(p.conns))
+
+	for _, conn := range p.conns {
+		stats = append(stats, PoolStats{
+			ServerId:       conn.serverId,
+			Host:           conn.host,
+			ActiveSessions: conn.active,
+			MaxSessions:    cap(conn.sessions),
+			Waiting:        conn.waiting,
+			Acquired:       conn.acquired,
+			ConnectedAt:    formatPoolTime(conn.connectedAt),
+			LastUsedAt:     formatPoolTime(conn.lastUsedAt),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ServerId < stats[j].ServerId
+	})
+
+	return PoolStatsResponse{
+		IdleTimeout:      p.idleTimeout.String(),
+		MaxSessions:      p.limits.MaxSessions,
+		MaxHostSessions:  p.limits.MaxHostSessions,
+		MaxTotalSessions: p.limits.MaxTotalSessions,
+		Connections:      stats,
+	}
+}
+
+// connection method returns healthy pooled connection of the Server or dials new one, dialing is not bound to the ctx,
+// so cancelled request does not fail other requests waiting for the same connection
+func (p *ConnectionPool) connection(ctx context.Context, server entity.Server) (*pooledConnection, error) {
+	for {
+		p.mu.Lock()
+		conn, shared := p.conns[server.Id]
+
+		if !shared {
+			conn = &pooledConnection{
+				serverId: server.Id,
+				host:     server.Host,
+				ready:    make(chan struct{}),
+			}
+
+			if p.limits.MaxSessions > 0 {
+				conn.sessions = make(chan struct{}, p.limits.MaxSessions)
+			}
+
+			p.conns[server.Id] = conn
+
+			go p.dial(context.WithoutCancel(ctx), conn, server)
+		}
+
+		p.mu.Unlock()
+
+		select {
+		case <-conn.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if conn.err != nil && shared {
+			return nil, &sharedDialError{err: conn.err}
+		}
+
+		if conn.err != nil {
+			return nil, conn.err
+		}
+
+		if !shared || p.healthy(conn) {
+			return conn, nil
+		}
+
+		p.l.Info("pooled connection is not healthy, reconnecting", slog.Int("serverId", conn.serverId))
+
+		p.mu.Lock()
+		p.discardLocked(conn)
+		p.mu.Unlock()
+	}
+}
+
+// dial method connects to the Server in time limited by connect timeouts of the Server and its jump hosts
+func (p *ConnectionPool) dial(ctx context.Context, conn *pooledConnection, server entity.Server) {
+	defer close(conn.ready)
+
+	var client *ssh.Client
+
+	via, timeout, err := p.connector.jumpChain(ctx, server)
+
+	if err == nil {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		client, err = p.connector.Connect(ctx, server)
+		cancel()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn.via, conn.client, conn.err = via, client, err
+
+	if err != nil {
+		// failed dialing is not cached, the next acquiring tries again
+		if p.conns[conn.serverId] == conn {
+			delete(p.conns, conn.serverId)
+		}
+
+		return
+	}
+
+	now := time.Now()
+	conn.connectedAt = now
+	conn.lastUsedAt = now
+	conn.lastCheckedAt = now
+
+	go func() {
+		conn.client.Wait()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.discardLocked(conn)
+	}()
+}
+
+// healthy method sends keepalive request over connections which have not been checked recently
+func (p *ConnectionPool) healthy(conn *pooledConnection) bool {
+	p.mu.Lock()
+	checked := time.Since(conn.lastCheckedAt) < healthCheckInterval
+	p.mu.Unlock()
+
+	if checked {
+		return true
+	}
+
+	result := make(chan error, 1)
+
+	go func() {
+		_, _, err := conn.client.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+
+	var err error
+
+	select {
+	case err = <-result:
+	case <-time.After(healthCheckTimeout):
+		err = errors.New("keepalive timeout")
+	}
+
+	if err != nil {
+		return false
+	}
+
+	p.mu.Lock()
+	conn.lastCheckedAt = time.Now()
+	p.mu.Unlock()
+
+	return true
+}
+
+// closeIdle closes connections without clients which are not used longer than idle timeout
+func (p *ConnectionPool) closeIdle() {
+	interval := max(p.idleTimeout/2, time.Second)
+
+	for range time.Tick(interval) {
+		p.mu.Lock()
+
+		for _, conn := range p.conns {
+			select {
+			case <-conn.ready:
+			default:
+				continue
+			}
+
+			if conn.users == 0 && time.Since(conn.lastUsedAt) > p.idleTimeout {
+				p.discardLocked(conn)
+			}
+		}
+
+		p.mu.Unlock()
+	}
+}
+
+// discardLocked removes the connection from the pool and closes it when it is not used,
+// the caller must hold the mutex
+func (p *ConnectionPool) discardLocked(conn *pooledConnection) {
+	if p.conns[conn.serverId] == conn {
+		delete(p.conns, conn.serverId)
+	}
+
+	conn.invalid = true
+
+	if conn.users == 0 && conn.client != nil {
+		conn.client.Close()
+	}
+}
+
+func formatPoolTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// jumpChain method returns ids of jump servers of the Server and the total connect timeout of all hops
+func (c *SSHConnector) jumpChain(ctx context.Context, server entity.Server) ([]int, time.Duration, error) {
+	jumps, err := resolveJumpChain(ctx, c.servers, server)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
+	}
+
+	ids := make([]int, len(jumps))
+	timeout := connectTimeout(server)
+
+	for i, jump := range jumps {
+		ids[i] = jump.Id
+		timeout += connectTimeout(jump)
+	}
+
+	return ids, timeout, nil
+}
+
+// A sessionClient opens ssh sessions over the client, sessions of the pooled connection take slots of the pool limits
+type sessionClient struct {
+	client *ssh.Client
+	// acquire reserves slot of the session, nil func means sessions are not limited
+	acquire func(ctx context.Context) (func(), error)
+}
+
+// newSessionClient returns client which opens sessions over the ssh client without limits
+func newSessionClient(client *ssh.Client) *sessionClient {
+	return &sessionClient{client: client}
+}
+
+// NewSession method opens new session when there is a free slot, the returned func must be called
+// after the session is closed
+func (c *sessionClient) NewSession(ctx context.Context) (*ssh.Session, func(), error) {
+	release := func() {}
+
+	if c.acquire != nil {
+		var err error
+
+		if release, err = c.acquire(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	session, err := c.client.NewSession()
+
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	return session, release, nil
+}
+
+// A sharedDialError is a failure of dialing started by another request, the failure is counted
+// by circuit breaker only once for the request which started dialing
+type sharedDialError struct {
+	err error
+}
+
+func (e *sharedDialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sharedDialError) Unwrap() error {
+	return e.err
+}
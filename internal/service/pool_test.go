@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConnectionPoolAcquireSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits PoolLimits
+		// held are indexes of connections whose sessions are opened before the checked one
+		held    []int
+		conn    int
+		wantErr error
+	}{
+		{name: "no limits", held: []int{0, 0, 1}, conn: 0},
+		{name: "free session of the connection", limits: PoolLimits{MaxSessions: 2}, held: []int{0}, conn: 0},
+		{name: "connection limit reached", limits: PoolLimits{MaxSessions: 1}, held: []int{0}, conn: 0, wantErr: context.DeadlineExceeded},
+		{name: "connection limit of another connection", limits: PoolLimits{MaxSessions: 1}, held: []int{0}, conn: 1},
+		{name: "host limit reached", limits: PoolLimits{MaxHostSessions: 1}, held: []int{0}, conn: 1, wantErr: context.DeadlineExceeded},
+		{name: "host limit of another host", limits: PoolLimits{MaxHostSessions: 1}, held: []int{0}, conn: 2},
+		{name: "total limit reached", limits: PoolLimits{MaxTotalSessions: 2}, held: []int{0, 2}, conn: 1, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestConnectionPool(tt.limits)
+			conns := []*pooledConnection{
+				newTestPooledConnection(1, "10.0.0.1", tt.limits),
+				newTestPooledConnection(2, "10.0.0.1", tt.limits),
+				newTestPooledConnection(3, "10.0.0.2", tt.limits),
+			}
+
+			var releases []func()
+
+			for _, i := range tt.held {
+				release, err := p.acquireSession(context.Background(), conns[i])
+
+				if err != nil {
+					t.Fatalf("acquireSession() error = %v", err)
+				}
+
+				releases = append(releases, release)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			release, err := p.acquireSession(ctx, conns[tt.conn])
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("acquireSession() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil {
+				release()
+			}
+
+			if waiting := conns[tt.conn].waiting; waiting != 0 {
+				t.Errorf("waiting = %d after acquiring, want 0", waiting)
+			}
+
+			for _, release := range releases {
+				release()
+				release()
+			}
+
+			// every limit is free after held sessions are released
+			release, err = p.acquireSession(context.Background(), conns[tt.conn])
+
+			if err != nil {
+				t.Fatalf("acquireSession() after release error = %v", err)
+			}
+
+			release()
+
+			for _, conn := range conns {
+				if conn.active != 0 {
+					t.Errorf("active sessions of server %d = %d after release, want 0", conn.serverId, conn.active)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectionPoolInvalidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		serverId int
+		want     []int
+	}{
+		{name: "server without pooled connection", serverId: 9, want: []int{1, 2, 3, 4}},
+		{name: "server without dependents", serverId: 4, want: []int{1, 2, 3}},
+		{name: "jump server", serverId: 1, want: []int{4}},
+		{name: "the last hop", serverId: 2, want: []int{1, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestConnectionPool(PoolLimits{})
+			conns := map[int][]int{1: nil, 2: {1}, 3: {1, 2}, 4: nil}
+
+			for serverId, via := range conns {
+				conn := newTestPooledConnection(serverId, "10.0.0.1", PoolLimits{})
+				conn.via = via
+				p.conns[serverId] = conn
+			}
+
+			p.Invalidate(tt.serverId)
+
+			var got []int
+
+			for _, stats := range p.Stats().Connections {
+				got = append(got, stats.ServerId)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Invalidate(%d) kept connections %v, want %v", tt.serverId, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionPoolStats(t *testing.T) {
+	limits := PoolLimits{MaxSessions: 4, MaxHostSessions: 8, MaxTotalSessions: 16}
+	p := newTestConnectionPool(limits)
+
+	for _, serverId := range []int{3, 1, 2} {
+		p.conns[serverId] = newTestPooledConnection(serverId, "10.0.0.1", limits)
+	}
+
+	release, err := p.acquireSession(context.Background(), p.conns[2])
+
+	if err != nil {
+		t.Fatalf("acquireSession() error = %v", err)
+	}
+
+	stats := p.Stats()
+	release()
+
+	ids := make([]int, len(stats.Connections))
+
+	for i, conn := range stats.Connections {
+		ids[i] = conn.ServerId
+	}
+
+	if !sort.IntsAreSorted(ids) || len(ids) != 3 {
+		t.Errorf("Stats() connections %v, want sorted by server id", ids)
+	}
+
+	want := PoolStats{ServerId: 2, Host: "10.0.0.1", ActiveSessions: 1, MaxSessions: 4, Acquired: 1}
+
+	if got := stats.Connections[1]; got != want {
+		t.Errorf("Stats() of server 2 = %+v, want %+v", got, want)
+	}
+
+	if stats.MaxSessions != 4 || stats.MaxHostSessions != 8 || stats.MaxTotalSessions != 16 {
+		t.Errorf("Stats() limits = %+v, want %+v", stats, limits)
+	}
+}
+
+func newTestConnectionPool(limits PoolLimits) *ConnectionPool {
+	p := &ConnectionPool{
+		limits:   limits,
+		breakers: newCircuitBreakers(limits.BreakerThreshold, limits.BreakerCooldown),
+		l:        discardLogger{},
+		hosts:    map[string]chan struct{}{},
+		conns:    map[int]*pooledConnection{},
+	}
+
+	if limits.MaxTotalSessions > 0 {
+		p.total = make(chan struct{}, limits.MaxTotalSessions)
+	}
+
+	return p
+}
+
+func newTestPooledConnection(serverId int, host string, limits PoolLimits) *pooledConnection {
+	conn := &pooledConnection{serverId: serverId, host: host, ready: make(chan struct{})}
+
+	if limits.MaxSessions > 0 {
+		conn.sessions = make(chan struct{}, limits.MaxSessions)
+	}
+
+	return conn
+}
+
+func TestTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "timeout", err: fmt.Errorf("dial: %w", context.DeadlineExceeded), want: true},
+		{name: "refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: true},
+		{name: "unreachable", err: fmt.Errorf("dial: %w", syscall.ENETUNREACH), want: true},
+		{name: "reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "closed during handshake", err: fmt.Errorf("ssh: handshake failed: %w", io.EOF), want: true},
+		{name: "authentication", err: errors.New("ssh: handshake failed: ssh: unable to authenticate")},
+		{name: "host key", err: fmt.Errorf("%w: offered another key", ErrHostKeyMismatch)},
+		{name: "unknown host", err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientError(tt.err); got != tt.want {
+				t.Errorf("transientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
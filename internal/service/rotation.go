@@ -0,0 +1,291 @@
+package service
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// rotationSuffix matches suffixes left by logrotate: numbered app.log.1 and dated app.log-20261017,
+// optionally followed by the compression extension
+var rotationSuffix = regexp.MustCompile(`^(?:\.(\d+)|[.-](\d{8}(?:\d{2,6})?))(?:\.(gz|bz2|xz|zst))?$`)
+
+// A rotatedFile is a log file or one of its rotated siblings
+type rotatedFile struct {
+	path string
+	// index is a number of numbered rotation, zero for the current file and dated rotations
+	index int
+	// date is a suffix of dated rotation
+	date string
+	// compression is an extension of compressed rotation
+	compression string
+}
+
+// A rotationGroup is a log file with its rotated siblings ordered from the oldest to the current one
+type rotationGroup struct {
+	name  string
+	files []rotatedFile
+}
+
+// globRotations returns files matched by the pattern grouped with their rotated siblings
+func globRotations(ctx context.Context, reader LogReader, pattern string) ([]rotationGroup, error) {
+	files, err := reader.Glob(ctx, pattern+"*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupRotations(pattern, files), nil
+}
+
+// groupRotations groups files matched by the pattern followed by a wildcard by their log file, files which are neither matched
+// by the pattern nor rotations of matched files are skipped
+func groupRotations(pattern string, files []string) []rotationGroup {
+	groups := map[string]*rotationGroup{}
+
+	for _, file := range files {
+		name, rotated := splitRotation(file, pattern)
+
+		if matched, _ := path.Match(pattern, name); !matched {
+			continue
+		}
+
+		group, ok := groups[name]
+
+		if !ok {
+			group = &rotationGroup{name: name}
+			groups[name] = group
+		}
+
+		group.files = append(group.files, rotated)
+	}
+
+	result := make([]rotationGroup, 0, len(groups))
+
+	for _, group := range groups {
+		sort.SliceStable(group.files, func(i, j int) bool {
+			return group.files[i].older(group.files[j])
+		})
+
+		result = append(result, *group)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+
+	return result
+}
+
+// splitRotation returns the log file name of the file and its rotation, a file matched by the pattern
+// is a current log file unless the name without rotation suffix is matched too
+func splitRotation(file, pattern string) (string, rotatedFile) {
+	dir, base := path.Split(file)
+
+	for i := len(base) - 1; i > 0; i-- {
+		if base[i] != '.' && base[i] != '-' {
+			continue
+		}
+
+		m := rotationSuffix.FindStringSubmatch(base[i:])
+
+		if m == nil {
+			continue
+		}
+
+		name := dir + base[:i]
+
+		if matched, _ := path.Match(pattern, name); !matched {
+			continue
+		}
+
+		index, _ := strconv.Atoi(m[1])
+
+		return name, rotatedFile{path: file, index: index, date: m[2], compression: m[3]}
+	}
+
+	return file, rotatedFile{path: file}
+}
+
+// older reports whether the f is rotated before the other, dated rotations precede numbered ones
+// and the current file is always the last
+func (f rotatedFile) older(other rotatedFile) bool {
+	if f.current() || other.current() {
+		return !f.current() && other.current()
+	}
+
+	if f.date != "" || other.date != "" {
+		if f.date == "" || other.date == "" {
+			return other.date == ""
+		}
+
+		return f.date < other.date
+	}
+
+	return f.index > other.index
+}
+
+func (f rotatedFile) current() bool {
+	return f.index == 0 && f.date == ""
+}
+
+// decompress wraps content of the file by decompressor of its compression
+func (f rotatedFile) decompress(rc io.ReadCloser) (io.ReadCloser, error) {
+	var (
+		r   io.Reader
+		err error
+	)
+
+	switch f.compression {
+	case "":
+		return rc, nil
+	case "gz":
+		r, err = gzip.NewReader(rc)
+	case "bz2":
+		r = bzip2.NewReader(rc)
+	case "xz":
+		r, err = xz.NewReader(rc)
+	case "zst":
+		var d *zstd.Decoder
+
+		if d, err = zstd.NewReader(rc); err == nil {
+			r = d.IOReadCloser()
+		}
+	default:
+		err = fmt.Errorf("unsupported compression %q", f.compression)
+	}
+
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	return &decompressedReader{Reader: r, file: rc}, nil
+}
+
+// A decompressedReader is a decompressed content of the file, closing it closes the decompressor and the file
+type decompressedReader struct {
+	io.Reader
+	file io.Closer
+}
+
+func (d *decompressedReader) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		c.Close()
+	}
+
+	return d.file.Close()
+}
+
+// A rotationReader reads files of the rotationGroup one by one as a single stream, files are opened lazily
+// and every file is terminated by a line break, so the last line of a file is not joined with the next one,
+// when filter is not nil lines are filtered on the Server if the reader supports it
+type rotationReader struct {
+	ctx     context.Context
+	reader  LogReader
+	files   []rotatedFile
+	filter  *LineFilter
+	meta    *LogsMeta
+	current io.ReadCloser
+	newline bool
+}
+
+func newRotationReader(ctx context.Context, reader LogReader, group rotationGroup, filter *LineFilter, meta *LogsMeta) *rotationReader {
+	return &rotationReader{ctx: ctx, reader: reader, files: group.files, filter: filter, meta: meta}
+}
+
+func (r *rotationReader) Read(p []byte) (int, error) {
+	for {
+		if r.newline {
+			r.newline = false
+			p[0] = '\n'
+
+			return 1, nil
+		}
+
+		if r.current == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+
+			if err := r.open(r.files[0]); err != nil {
+				return 0, err
+			}
+
+			r.files = r.files[1:]
+		}
+
+		n, err := r.current.Read(p)
+
+		if err == io.EOF {
+			err = r.current.Close()
+			r.current = nil
+			r.newline = true
+
+			if err != nil {
+				return n, err
+			}
+
+			if n == 0 {
+				continue
+			}
+
+			return n, nil
+		}
+
+		return n, err
+	}
+}
+
+// open method opens the file, bytes of filtered file are counted as transferred while its size is counted as scanned,
+// other files are transferred entirely
+func (r *rotationReader) open(file rotatedFile) error {
+	if f, ok := r.reader.(FilteringReader); ok && r.filter != nil && f.Filters(file.compression) {
+		rc, size, err := f.OpenFiltered(r.ctx, file.path, file.compression, *r.filter)
+
+		if err != nil {
+			return fmt.Errorf("can not open %s: %w", file.path, err)
+		}
+
+		r.meta.BytesScanned += size
+		r.meta.Prefiltered = true
+		r.current = &countingReader{ReadCloser: rc, counters: []*int64{&r.meta.BytesTransferred}}
+
+		return nil
+	}
+
+	rc, err := r.reader.Open(r.ctx, file.path)
+
+	if err != nil {
+		return fmt.Errorf("can not open %s: %w", file.path, err)
+	}
+
+	rc = &countingReader{ReadCloser: rc, counters: []*int64{&r.meta.BytesScanned, &r.meta.BytesTransferred}}
+
+	if r.current, err = file.decompress(rc); err != nil {
+		return fmt.Errorf("can not decompress %s: %w", file.path, err)
+	}
+
+	return nil
+}
+
+// Close method closes the file which is being read
+func (r *rotationReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+
+	err := r.current.Close()
+	r.current = nil
+
+	return err
+}
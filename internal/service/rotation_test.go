@@ -0,0 +1,104 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupRotations(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		files   []string
+		want    map[string][]string
+	}{
+		{
+			name:    "numbered rotations",
+			pattern: "/var/log/app.log",
+			files:   []string{"/var/log/app.log", "/var/log/app.log.1", "/var/log/app.log.10.gz", "/var/log/app.log.2.gz"},
+			want:    map[string][]string{"/var/log/app.log": {"/var/log/app.log.10.gz", "/var/log/app.log.2.gz", "/var/log/app.log.1", "/var/log/app.log"}},
+		},
+		{
+			name:    "dated rotations precede numbered ones",
+			pattern: "/var/log/app.log",
+			files:   []string{"/var/log/app.log", "/var/log/app.log-20261017.bz2", "/var/log/app.log-20261016", "/var/log/app.log.1"},
+			want:    map[string][]string{"/var/log/app.log": {"/var/log/app.log-20261016", "/var/log/app.log-20261017.bz2", "/var/log/app.log.1", "/var/log/app.log"}},
+		},
+		{
+			name:    "rotations without current file",
+			pattern: "/var/log/app.log",
+			files:   []string{"/var/log/app.log.1.xz", "/var/log/app.log.2.zst"},
+			want:    map[string][]string{"/var/log/app.log": {"/var/log/app.log.2.zst", "/var/log/app.log.1.xz"}},
+		},
+		{
+			name:    "glob pattern",
+			pattern: "/var/log/*.log",
+			files:   []string{"/var/log/a.log", "/var/log/a.log.1", "/var/log/b.log", "/var/log/b.log.1.gz", "/var/log/c.txt"},
+			want: map[string][]string{
+				"/var/log/a.log": {"/var/log/a.log.1", "/var/log/a.log"},
+				"/var/log/b.log": {"/var/log/b.log.1.gz", "/var/log/b.log"},
+			},
+		},
+		{
+			name:    "file matched by the pattern is not a rotation",
+			pattern: "/var/log/app.log*",
+			files:   []string{"/var/log/app.log.1", "/var/log/app.log.backup"},
+			want: map[string][]string{
+				"/var/log/app.log":        {"/var/log/app.log.1"},
+				"/var/log/app.log.backup": {"/var/log/app.log.backup"},
+			},
+		},
+		{
+			name:    "unrelated siblings",
+			pattern: "/var/log/app.log",
+			files:   []string{"/var/log/app.log", "/var/log/app.logger", "/var/log/app.log.old"},
+			want:    map[string][]string{"/var/log/app.log": {"/var/log/app.log"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string][]string{}
+
+			for _, group := range groupRotations(tt.pattern, tt.files) {
+				for _, file := range group.files {
+					got[group.name] = append(got[group.name], file.path)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupRotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatedFileOlder(t *testing.T) {
+	current := rotatedFile{path: "app.log"}
+	first := rotatedFile{path: "app.log.1", index: 1}
+	second := rotatedFile{path: "app.log.2.gz", index: 2, compression: "gz"}
+	early := rotatedFile{path: "app.log-20261016", date: "20261016"}
+	late := rotatedFile{path: "app.log-20261017", date: "20261017"}
+
+	tests := []struct {
+		f, other rotatedFile
+		want     bool
+	}{
+		{f: first, other: current, want: true},
+		{f: current, other: first, want: false},
+		{f: current, other: current, want: false},
+		{f: second, other: first, want: true},
+		{f: first, other: second, want: false},
+		{f: early, other: late, want: true},
+		{f: late, other: early, want: false},
+		{f: late, other: second, want: true},
+		{f: second, other: late, want: false},
+		{f: early, other: current, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.f.older(tt.other); got != tt.want {
+			t.Errorf("%s.older(%s) = %v, want %v", tt.f.path, tt.other.path, got, tt.want)
+		}
+	}
+}
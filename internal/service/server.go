@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -24,48 +25,105 @@ type ServerStorager interface {
 	GetById(ctx context.Context, id int) (*entity.Server, error)
 	DeleteById(ctx context.Context, id int) error
 	GetList(ctx context.Context, limit, page int) ([]entity.Server, error)
+	GetListByCredentialId(ctx context.Context, credentialId int) ([]entity.Server, error)
+	GetListByJumpServerId(ctx context.Context, jumpServerId int) ([]entity.Server, error)
 	Update(ctx context.Context, server *entity.Server, id int) error
 }
 
+// ErrServerInUse is returned when deleted Server is a jump server of other servers
+type ErrServerInUse struct {
+	Servers []ServerReference `json:"servers"`
+}
+
+func (e ErrServerInUse) Error() string {
+	return fmt.Sprintf("server is a jump server of %d servers", len(e.Servers))
+}
+
+// A ServerReference is a Server which refers to the deleted entity, e.g. uses the Credential or the jump server
+type ServerReference struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func createServerReferences(servers []entity.Server) []ServerReference {
+	references := make([]ServerReference, len(servers))
+
+	for i, server := range servers {
+		references[i] = ServerReference{Id: server.Id, Name: server.Name}
+	}
+
+	return references
+}
+
 type Validator interface {
 	Struct(s interface{}) error
 }
 
+// A ServerData contains server fields, zero connection options are replaced by defaults,
+// empty Kind means ssh server
 type ServerData struct {
-	Name          string `json:"name"`
-	Host          string `json:"host"`
-	CredentialId  int    `json:"credentialId"`
-	LogFolderPath string `json:"logFolderPath"`
-	LogFormat     string `json:"logFormat"`
+	Name              string `json:"name"`
+	Kind              string `json:"kind"`
+	Host              string `json:"host"`
+	Port              int    `json:"port"`
+	Username          string `json:"username"`
+	ConnectTimeout    int    `json:"connectTimeout"`
+	KeepAliveInterval int    `json:"keepAliveInterval"`
+	KeepAliveCountMax int    `json:"keepAliveCountMax"`
+	UseSudo           bool   `json:"useSudo"`
+	Transport         string `json:"transport"`
+	CredentialId      int    `json:"credentialId"`
+	JumpServerId      int    `json:"jumpServerId"`
 }
 
 type ServerResponse struct {
-	Id            int    `json:"id"`
-	Name          string `json:"name"`
-	Host          string `json:"host"`
-	LogFolderPath string `json:"log_folder_path"`
-	LogFormat     string `json:"log_format"`
-	CredentialId  int    `json:"credentialId"`
-	CreatedAt     string `json:"createdAt"`
-	UpdatedAt     string `json:"updatedAt"`
+	Id                int    `json:"id"`
+	Name              string `json:"name"`
+	Kind              string `json:"kind"`
+	Host              string `json:"host"`
+	Port              int    `json:"port"`
+	Username          string `json:"username,omitempty"`
+	ConnectTimeout    int    `json:"connectTimeout"`
+	KeepAliveInterval int    `json:"keepAliveInterval"`
+	KeepAliveCountMax int    `json:"keepAliveCountMax"`
+	UseSudo           bool   `json:"useSudo"`
+	Transport         string `json:"transport"`
+	CredentialId      int    `json:"credentialId"`
+	JumpServerId      int    `json:"jumpServerId,omitempty"`
+	CreatedAt         string `json:"createdAt"`
+	UpdatedAt         string `json:"updatedAt"`
+	// CircuitBreaker shows whether logman stopped connecting to the Server after repeated failures
+	CircuitBreaker BreakerStatus `json:"circuitBreaker"`
 }
 
-type LogLocationModel struct {
-	Path   string `json:"path"`
-	Format string `json:"format"`
+// A DryRunResponse contains Server which would be saved and result of its connectivity check
+type DryRunResponse struct {
+	Server ServerResponse `json:"server"`
+	Check  CheckResponse  `json:"check"`
 }
 
 type ServerService struct {
 	storage           ServerStorager
 	credentialStorage CredentialStorager
+	locationStorage   LogLocationStorager
+	knownHostStorage  KnownHostStorager
+	connections       ConnectionManager
+	checker           *ServerChecker
+	localRoots        []string
 	l                 Logger
 	v                 Validator
 }
 
-func NewServerService(storage ServerStorager, credentialStorage CredentialStorager, l Logger, v Validator) *ServerService {
+func NewServerService(storage ServerStorager, credentialStorage CredentialStorager, locationStorage LogLocationStorager, knownHostStorage KnownHostStorager, connections ConnectionManager, checker *ServerChecker, localRoots []string, l Logger, v Validator) *ServerService {
 	return &ServerService{
 		storage:           storage,
 		credentialStorage: credentialStorage,
+		locationStorage:   locationStorage,
+		knownHostStorage:  knownHostStorage,
+		connections:       connections,
+		checker:           checker,
+		localRoots:        localRoots,
+		l:                 l,
 		v:                 v,
 	}
 }
@@ -83,53 +141,113 @@ func (s *ServerService) FetchById(ctx context.Context, id int) (*ServerResponse,
 		return nil, nil
 	}
 
-	return createServerResponseFromServerEntity(*server), nil
+	return s.response(*server), nil
 }
 
 func (s *ServerService) Create(ctx context.Context, data ServerData) (*ServerResponse, error) {
+	server, err := s.newServer(ctx, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Create(ctx, server); err != nil {
+		s.l.Error("error during creating server", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("error during creating server: %w", err)
+	}
+
+	return s.response(*server), nil
+}
 
-	credential, err := s.credentialStorage.GetById(ctx, data.CredentialId)
+// CreateDryRun method validates the data and checks connectivity of the Server without saving it
+func (s *ServerService) CreateDryRun(ctx context.Context, data ServerData) (*DryRunResponse, error) {
+	server, err := s.newServer(ctx, data)
 
 	if err != nil {
-		s.l.Error("error during Credential search by id", slog.String("error", err.Error()))
+		return nil, err
+	}
 
-		return nil, fmt.Errorf("error during Credential search by id: %w", err)
+	return &DryRunResponse{
+		Server: *s.response(*server),
+		Check:  s.checker.Check(ctx, *server, true),
+	}, nil
+}
+
+// Check method checks connectivity of the stored Server, in case when Server is not found the method will return nil
+func (s *ServerService) Check(ctx context.Context, id int) (*CheckResponse, error) {
+	server, err := s.storage.GetById(ctx, id)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Server search by id: %w", err)
 	}
 
-	if credential == nil {
-		return nil, ErrValidation{Errors: []string{fmt.Sprintf("credential with id %d not found", data.CredentialId)}}
+	if server == nil {
+		return nil, nil
 	}
 
+	response := s.checker.Check(ctx, *server, false)
+
+	return &response, nil
+}
+
+// newServer method builds and validates new Server from the data
+func (s *ServerService) newServer(ctx context.Context, data ServerData) (*entity.Server, error) {
 	now := time.Now()
 
 	server := &entity.Server{
-		Name:          data.Name,
-		Host:          data.Host,
-		CredentialId:  credential.Id,
-		LogFolderPath: entity.LogFolderPath(data.LogFolderPath),
-		LogFormat:     entity.LogFormat(data.LogFormat),
-		CreatedAt:     now.Format(time.RFC3339),
-		UpdatedAt:     now.Format(time.RFC3339),
+		Name:              data.Name,
+		Kind:              data.Kind,
+		Host:              data.Host,
+		Port:              data.Port,
+		Username:          data.Username,
+		ConnectTimeout:    data.ConnectTimeout,
+		KeepAliveInterval: data.KeepAliveInterval,
+		KeepAliveCountMax: data.KeepAliveCountMax,
+		UseSudo:           data.UseSudo,
+		Transport:         data.Transport,
+		CredentialId:      data.CredentialId,
+		JumpServerId:      data.JumpServerId,
+		CreatedAt:         now.Format(time.RFC3339),
+		UpdatedAt:         now.Format(time.RFC3339),
 	}
 
+	applyServerDefaults(server)
+
 	if err := s.v.Struct(server); err != nil {
 		return nil, buildValidationError(err)
 	}
 
-	if err := s.storage.Create(ctx, server); err != nil {
-		s.l.Error("error during creating server", slog.String("error", err.Error()))
-		return nil, fmt.Errorf("error during creating server: %w", err)
+	if err := s.validateCredential(ctx, *server); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateJumpChain(ctx, *server); err != nil {
+		return nil, err
 	}
 
-	return createServerResponseFromServerEntity(*server), nil
+	return server, nil
 }
 
+// DeleteById method deletes Server which is not a jump server of other servers, otherwise ErrServerInUse is returned
 func (s *ServerService) DeleteById(ctx context.Context, id int) error {
+	servers, err := s.storage.GetListByJumpServerId(ctx, id)
+
+	if err != nil {
+		return fmt.Errorf("error during Server search by jump server id: %w", err)
+	}
+
+	if len(servers) > 0 {
+		return ErrServerInUse{Servers: createServerReferences(servers)}
+	}
+
+	// log locations and pinned host key of the Server are deleted by the database
 	if err := s.storage.DeleteById(ctx, id); err != nil {
 		s.l.Error("delete by id failed", slog.String("error", err.Error()))
 		return fmt.Errorf("delete by id failed: %w", err)
 	}
 
+	s.connections.Invalidate(id)
+
 	return nil
 }
 
@@ -144,53 +262,311 @@ func (s *ServerService) GetList(ctx context.Context, limit, page int) ([]ServerR
 	responses := make([]ServerResponse, len(servers))
 
 	for i, server := range servers {
-		responses[i] = *createServerResponseFromServerEntity(server)
+		responses[i] = *s.response(server)
 	}
 
 	return responses, nil
 }
 
 func (s *ServerService) Update(ctx context.Context, id int, data ServerData) (*ServerResponse, error) {
-	server, err := s.storage.GetById(ctx, id)
+	previous, server, err := s.updatedServer(ctx, id, data)
+
+	if err != nil || server == nil {
+		return nil, err
+	}
+
+	if err := s.storage.Update(ctx, server, id); err != nil {
+		return nil, fmt.Errorf("error during updating server: %w", err)
+	}
+
+	// the pinned key belongs to the previous address, the key of the new address is pinned on the next connection
+	if previous.Host != server.Host || previous.Port != server.Port {
+		if err := s.knownHostStorage.DeleteByServerId(ctx, id); err != nil {
+			s.l.Error("delete of pinned host key failed", slog.String("error", err.Error()))
+			return nil, fmt.Errorf("delete of pinned host key failed: %w", err)
+		}
+	}
+
+	if connectionChanged(*previous, *server) {
+		s.connections.Invalidate(id)
+	}
+
+	return s.response(*server), nil
+}
+
+// UpdateDryRun method validates the data and checks connectivity of the updated Server without saving it,
+// in case when Server is not found the method will return nil
+func (s *ServerService) UpdateDryRun(ctx context.Context, id int, data ServerData) (*DryRunResponse, error) {
+	_, server, err := s.updatedServer(ctx, id, data)
+
+	if err != nil || server == nil {
+		return nil, err
+	}
+
+	return &DryRunResponse{
+		Server: *s.response(*server),
+		Check:  s.checker.Check(ctx, *server, true),
+	}, nil
+}
+
+// updatedServer method returns stored Server and its validated copy updated by the data,
+// in case when Server is not found the method will return nil
+func (s *ServerService) updatedServer(ctx context.Context, id int, data ServerData) (*entity.Server, *entity.Server, error) {
+	previous, err := s.storage.GetById(ctx, id)
 
 	if err != nil {
-		return nil, fmt.Errorf("error during Server search by id: %w", err)
+		return nil, nil, fmt.Errorf("error during Server search by id: %w", err)
 	}
 
-	if server == nil {
-		return nil, nil
+	if previous == nil {
+		return nil, nil, nil
 	}
 
 	now := time.Now()
+	server := *previous
 
 	server.Name = data.Name
+	server.Kind = data.Kind
 	server.Host = data.Host
-	server.LogFolderPath = entity.LogFolderPath(data.LogFolderPath)
-	server.LogFormat = entity.LogFormat(data.LogFormat)
+	server.Port = data.Port
+	server.Username = data.Username
+	server.ConnectTimeout = data.ConnectTimeout
+	server.KeepAliveInterval = data.KeepAliveInterval
+	server.KeepAliveCountMax = data.KeepAliveCountMax
+	server.UseSudo = data.UseSudo
+	server.Transport = data.Transport
 	server.UpdatedAt = now.Format(time.RFC3339)
 	server.CredentialId = data.CredentialId
+	server.JumpServerId = data.JumpServerId
+
+	applyServerDefaults(&server)
 
 	if err := s.v.Struct(server); err != nil {
-		return nil, buildValidationError(err)
+		return nil, nil, buildValidationError(err)
 	}
 
-	if err := s.storage.Update(ctx, server, id); err != nil {
-		return nil, fmt.Errorf("error during updating server: %w", err)
+	if err := s.validateTransport(ctx, server); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.validateLocalLocations(ctx, server); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.validateCredential(ctx, server); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.validateJumpChain(ctx, server); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.validateDependentJumpChains(ctx, server); err != nil {
+		return nil, nil, err
 	}
 
-	return createServerResponseFromServerEntity(*server), nil
+	return previous, &server, nil
+}
+
+// validateTransport method checks that the Transport is able to read every LogLocation of the stored Server
+func (s *ServerService) validateTransport(ctx context.Context, server entity.Server) error {
+	if server.Transport != entity.ServerTransportSftp {
+		return nil
+	}
+
+	locations, err := s.locationStorage.GetListByServerId(ctx, server.Id)
+
+	if err != nil {
+		return fmt.Errorf("error during LogLocation search by server id: %w", err)
+	}
+
+	if !readableBySftp(locations) {
+		return ErrValidation{Errors: []string{"invalid 'Transport' field, the server has log locations which can not be read over sftp"}}
+	}
+
+	return nil
+}
+
+// validateLocalLocations method checks that file locations of the stored Server are inside of local roots
+// when the Server is local, so a remote Server switched to local kind can not read arbitrary files of logman host
+func (s *ServerService) validateLocalLocations(ctx context.Context, server entity.Server) error {
+	if server.Kind != entity.ServerKindLocal {
+		return nil
+	}
+
+	locations, err := s.locationStorage.GetListByServerId(ctx, server.Id)
+
+	if err != nil {
+		return fmt.Errorf("error during LogLocation search by server id: %w", err)
+	}
+
+	var errs []string
+
+	for _, location := range locations {
+		if location.Kind == entity.LogLocationKindFile && !insideLocalRoots(location.Path, s.localRoots) {
+			errs = append(errs, fmt.Sprintf("invalid 'Kind' field, the path %s of log location %s is not inside of %s", location.Path, location.Name, strings.Join(s.localRoots, ", ")))
+		}
+	}
+
+	if len(errs) > 0 {
+		return ErrValidation{Errors: errs}
+	}
+
+	return nil
+}
+
+// validateCredential method checks that Credential of ssh Server exists
+func (s *ServerService) validateCredential(ctx context.Context, server entity.Server) error {
+	if server.Kind != entity.ServerKindSsh {
+		return nil
+	}
+
+	credential, err := s.credentialStorage.GetById(ctx, server.CredentialId)
+
+	if err != nil {
+		s.l.Error("error during Credential search by id", slog.String("error", err.Error()))
+
+		return fmt.Errorf("error during Credential search by id: %w", err)
+	}
+
+	if credential == nil {
+		return ErrValidation{Errors: []string{fmt.Sprintf("credential with id %d not found", server.CredentialId)}}
+	}
+
+	return nil
+}
+
+// validateJumpChain method checks that every jump server exists and jump servers do not form a cycle
+func (s *ServerService) validateJumpChain(ctx context.Context, server entity.Server) error {
+	_, err := resolveJumpChain(ctx, s.storage, server)
+
+	if errors.Is(err, errJumpServerNotFound) || errors.Is(err, errJumpChainCycle) || errors.Is(err, errJumpChainTooLong) || errors.Is(err, errJumpServerLocal) {
+		return ErrValidation{Errors: []string{fmt.Sprintf("invalid 'JumpServerId' field, %s", err)}}
+	}
+
+	if err != nil {
+		s.l.Error("error during jump chain resolving", slog.String("error", err.Error()))
+		return fmt.Errorf("error during jump chain resolving: %w", err)
+	}
+
+	return nil
+}
+
+// validateDependentJumpChains method checks that servers reached through the updated Server, directly or through
+// other jump servers, still have valid jump chains, e.g. the Server can not become local while it is a jump server
+func (s *ServerService) validateDependentJumpChains(ctx context.Context, server entity.Server) error {
+	updated := updatedServerStorage{ServerStorager: s.storage, server: server}
+	visited := map[int]bool{server.Id: true}
+	queue := []int{server.Id}
+
+	for len(queue) > 0 {
+		dependents, err := s.storage.GetListByJumpServerId(ctx, queue[0])
+		queue = queue[1:]
+
+		if err != nil {
+			return fmt.Errorf("error during Server search by jump server id: %w", err)
+		}
+
+		for _, dependent := range dependents {
+			if visited[dependent.Id] {
+				continue
+			}
+
+			visited[dependent.Id] = true
+			queue = append(queue, dependent.Id)
+
+			_, err := resolveJumpChain(ctx, updated, dependent)
+
+			if errors.Is(err, errJumpServerNotFound) || errors.Is(err, errJumpChainCycle) || errors.Is(err, errJumpChainTooLong) || errors.Is(err, errJumpServerLocal) {
+				return ErrValidation{Errors: []string{fmt.Sprintf("the server is a jump server of server %s with id %d, the update breaks its jump chain: %s", dependent.Name, dependent.Id, err)}}
+			}
+
+			if err != nil {
+				return fmt.Errorf("error during jump chain resolving: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// An updatedServerStorage returns the updated Server instead of the stored one, so jump chains are resolved
+// as they will be after the update is saved
+type updatedServerStorage struct {
+	ServerStorager
+	server entity.Server
+}
+
+func (u updatedServerStorage) GetById(ctx context.Context, id int) (*entity.Server, error) {
+	if id == u.server.Id {
+		server := u.server
+		return &server, nil
+	}
+
+	return u.ServerStorager.GetById(ctx, id)
+}
+
+// connectionChanged shows whether pooled connections of the server must be reopened after the update
+func connectionChanged(previous, current entity.Server) bool {
+	return previous.Kind != current.Kind ||
+		previous.Host != current.Host ||
+		previous.Port != current.Port ||
+		previous.Username != current.Username ||
+		previous.ConnectTimeout != current.ConnectTimeout ||
+		previous.KeepAliveInterval != current.KeepAliveInterval ||
+		previous.KeepAliveCountMax != current.KeepAliveCountMax ||
+		previous.CredentialId != current.CredentialId ||
+		previous.JumpServerId != current.JumpServerId
+}
+
+// applyServerDefaults sets default values of connection options which are not specified
+func applyServerDefaults(server *entity.Server) {
+	if server.Kind == "" {
+		server.Kind = entity.ServerKindSsh
+	}
+
+	if server.Port == 0 {
+		server.Port = entity.DefaultServerPort
+	}
+
+	if server.ConnectTimeout == 0 {
+		server.ConnectTimeout = entity.DefaultConnectTimeout
+	}
+
+	if server.KeepAliveCountMax == 0 {
+		server.KeepAliveCountMax = entity.DefaultKeepAliveCountMax
+	}
+
+	if server.Transport == "" {
+		server.Transport = entity.ServerTransportAuto
+	}
+}
+
+// response method returns ServerResponse with state of circuit breaker of the Server
+func (s *ServerService) response(server entity.Server) *ServerResponse {
+	response := createServerResponseFromServerEntity(server)
+	response.CircuitBreaker = s.connections.BreakerStatus(server.Id)
+
+	return response
 }
 
 func createServerResponseFromServerEntity(s entity.Server) *ServerResponse {
 	return &ServerResponse{
-		Id:            s.Id,
-		Name:          s.Name,
-		Host:          s.Host,
-		CredentialId:  s.CredentialId,
-		LogFolderPath: string(s.LogFolderPath),
-		LogFormat:     string(s.LogFormat),
-		CreatedAt:     s.CreatedAt,
-		UpdatedAt:     s.UpdatedAt,
+		Id:                s.Id,
+		Name:              s.Name,
+		Kind:              s.Kind,
+		Host:              s.Host,
+		Port:              s.Port,
+		Username:          s.Username,
+		ConnectTimeout:    s.ConnectTimeout,
+		KeepAliveInterval: s.KeepAliveInterval,
+		KeepAliveCountMax: s.KeepAliveCountMax,
+		UseSudo:           s.UseSudo,
+		Transport:         s.Transport,
+		CredentialId:      s.CredentialId,
+		JumpServerId:      s.JumpServerId,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
 	}
 }
 
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// memoryServerStorage keeps servers by id, methods which are not used by tests are not implemented
+type memoryServerStorage struct {
+	ServerStorager
+	servers map[int]entity.Server
+}
+
+func (m *memoryServerStorage) GetById(_ context.Context, id int) (*entity.Server, error) {
+	server, ok := m.servers[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &server, nil
+}
+
+func (m *memoryServerStorage) GetListByJumpServerId(_ context.Context, jumpServerId int) ([]entity.Server, error) {
+	var servers []entity.Server
+
+	for _, server := range m.servers {
+		if server.JumpServerId == jumpServerId {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+func (m *memoryServerStorage) GetListByCredentialId(_ context.Context, credentialId int) ([]entity.Server, error) {
+	var servers []entity.Server
+
+	for _, server := range m.servers {
+		if server.CredentialId == credentialId {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+func (m *memoryServerStorage) Create(_ context.Context, server *entity.Server) error {
+	server.Id = len(m.servers) + 1
+	m.servers[server.Id] = *server
+
+	return nil
+}
+
+func (m *memoryServerStorage) DeleteById(_ context.Context, id int) error {
+	delete(m.servers, id)
+
+	return nil
+}
+
+func (m *memoryServerStorage) Update(_ context.Context, server *entity.Server, id int) error {
+	m.servers[id] = *server
+
+	return nil
+}
+
+type memoryCredentialStorage struct {
+	CredentialStorager
+	credentials map[int]entity.Credential
+}
+
+func (m *memoryCredentialStorage) GetById(_ context.Context, id int) (*entity.Credential, error) {
+	credential, ok := m.credentials[id]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return &credential, nil
+}
+
+func (m *memoryCredentialStorage) Update(_ context.Context, credential *entity.Credential) error {
+	m.credentials[credential.Id] = *credential
+
+	return nil
+}
+
+type memoryLocationStorage struct {
+	LogLocationStorager
+	locations []entity.LogLocation
+}
+
+func (m *memoryLocationStorage) GetListByServerId(_ context.Context, serverId int) ([]entity.LogLocation, error) {
+	var locations []entity.LogLocation
+
+	for _, location := range m.locations {
+		if location.ServerId == serverId {
+			locations = append(locations, location)
+		}
+	}
+
+	return locations, nil
+}
+
+type recordingConnections struct {
+	invalidated []int
+}
+
+func (r *recordingConnections) Invalidate(serverId int) {
+	r.invalidated = append(r.invalidated, serverId)
+}
+
+func (r *recordingConnections) BreakerStatus(int) BreakerStatus {
+	return BreakerStatus{}
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Info(string, ...any)  {}
+func (discardLogger) Error(string, ...any) {}
+
+func newTestServerService(servers []entity.Server, locations []entity.LogLocation, knownHosts KnownHostStorager) *ServerService {
+	storage := &memoryServerStorage{servers: map[int]entity.Server{}}
+
+	for _, server := range servers {
+		storage.servers[server.Id] = server
+	}
+
+	return NewServerService(
+		storage,
+		&memoryCredentialStorage{credentials: map[int]entity.Credential{1: {Id: 1, Name: "deploy"}}},
+		&memoryLocationStorage{locations: locations},
+		knownHosts,
+		&recordingConnections{},
+		nil,
+		[]string{"/var/log"},
+		discardLogger{},
+		validator.New(),
+	)
+}
+
+func testSshServer(id int, host string) entity.Server {
+	server := entity.Server{
+		Id:           id,
+		Name:         host,
+		Host:         host,
+		CredentialId: 1,
+		CreatedAt:    "2026-10-18T00:00:00Z",
+		UpdatedAt:    "2026-10-18T00:00:00Z",
+	}
+
+	applyServerDefaults(&server)
+
+	return server
+}
+
+func testServerData(server entity.Server) ServerData {
+	return ServerData{
+		Name:              server.Name,
+		Kind:              server.Kind,
+		Host:              server.Host,
+		Port:              server.Port,
+		Username:          server.Username,
+		ConnectTimeout:    server.ConnectTimeout,
+		KeepAliveInterval: server.KeepAliveInterval,
+		KeepAliveCountMax: server.KeepAliveCountMax,
+		UseSudo:           server.UseSudo,
+		Transport:         server.Transport,
+		CredentialId:      server.CredentialId,
+		JumpServerId:      server.JumpServerId,
+	}
+}
+
+func TestServerServiceUpdateResetsPinnedKey(t *testing.T) {
+	server := testSshServer(1, "web")
+
+	tests := []struct {
+		name     string
+		update   func(data *ServerData)
+		wantPins bool
+	}{
+		{name: "name change keeps the pin", update: func(data *ServerData) { data.Name = "frontend" }, wantPins: true},
+		{name: "host change resets the pin", update: func(data *ServerData) { data.Host = "web2" }},
+		{name: "port change resets the pin", update: func(data *ServerData) { data.Port = 2222 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knownHosts := &memoryKnownHostStorage{hosts: map[int]entity.KnownHost{1: {ServerId: 1, Host: "web:22"}}}
+			s := newTestServerService([]entity.Server{server}, nil, knownHosts)
+
+			data := testServerData(server)
+			tt.update(&data)
+
+			if _, err := s.Update(context.Background(), server.Id, data); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			if _, pinned := knownHosts.hosts[1]; pinned != tt.wantPins {
+				t.Errorf("Update() pinned = %v, want %v", pinned, tt.wantPins)
+			}
+		})
+	}
+}
+
+// testJumpServers returns servers 1 <- 2 <- 3 where 2 jumps through 1 and 3 through 2, local server 4,
+// servers 20 and 21 which jump through each other and chain 10 <- 11 <- ... <- 17 of maximal length
+func testJumpServers() []entity.Server {
+	servers := []entity.Server{
+		testSshServer(1, "bastion"),
+		withJumpServer(testSshServer(2, "inner"), 1),
+		withJumpServer(testSshServer(3, "db"), 2),
+		{Id: 4, Name: "local", Kind: entity.ServerKindLocal},
+		withJumpServer(testSshServer(20, "left"), 21),
+		withJumpServer(testSshServer(21, "right"), 20),
+		testSshServer(10, "hop10"),
+	}
+
+	for id := 11; id < 10+maxJumpHops; id++ {
+		servers = append(servers, withJumpServer(testSshServer(id, "hop"), id-1))
+	}
+
+	return servers
+}
+
+func withJumpServer(server entity.Server, jumpServerId int) entity.Server {
+	server.JumpServerId = jumpServerId
+
+	return server
+}
+
+func TestServerServiceValidateJumpChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  entity.Server
+		wantErr bool
+	}{
+		{name: "without jump server", server: testSshServer(100, "web")},
+		{name: "chain of two jump servers", server: withJumpServer(testSshServer(100, "web"), 2)},
+		{name: "chain of maximal length", server: withJumpServer(testSshServer(100, "web"), 10+maxJumpHops-1)},
+		{name: "too long chain", server: withJumpServer(testSshServer(100, "web"), 10+maxJumpHops), wantErr: true},
+		{name: "not found jump server", server: withJumpServer(testSshServer(100, "web"), 99), wantErr: true},
+		{name: "local jump server", server: withJumpServer(testSshServer(100, "web"), 4), wantErr: true},
+		{name: "jump servers form a cycle", server: withJumpServer(testSshServer(100, "web"), 20), wantErr: true},
+		{name: "server reaches itself", server: withJumpServer(testSshServer(1, "bastion"), 3), wantErr: true},
+	}
+
+	servers := append(testJumpServers(), withJumpServer(testSshServer(10+maxJumpHops, "hop"), 10+maxJumpHops-1))
+	s := newTestServerService(servers, nil, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateJumpChain(context.Background(), tt.server)
+
+			if tt.wantErr && !errors.As(err, &ErrValidation{}) {
+				t.Errorf("validateJumpChain() error = %v, want ErrValidation", err)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateJumpChain() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestServerServiceUpdateKeepsDependentJumpChains(t *testing.T) {
+	servers := append(testJumpServers(), withJumpServer(testSshServer(50, "behind hops"), 10+maxJumpHops-1))
+
+	tests := []struct {
+		name    string
+		id      int
+		update  func(data *ServerData)
+		wantErr bool
+	}{
+		{
+			name:   "rename of jump server",
+			id:     1,
+			update: func(data *ServerData) { data.Name = "gateway" },
+		},
+		{
+			name: "jump server becomes local",
+			id:   1,
+			update: func(data *ServerData) {
+				*data = ServerData{Name: "bastion", Kind: entity.ServerKindLocal}
+			},
+			wantErr: true,
+		},
+		{
+			name: "server without dependents becomes local",
+			id:   3,
+			update: func(data *ServerData) {
+				*data = ServerData{Name: "db", Kind: entity.ServerKindLocal}
+			},
+		},
+		{
+			name:    "jump server jumps through its dependent",
+			id:      1,
+			update:  func(data *ServerData) { data.JumpServerId = 3 },
+			wantErr: true,
+		},
+		{
+			name:    "longer chain of jump server exceeds hops of dependents",
+			id:      10,
+			update:  func(data *ServerData) { data.JumpServerId = 1 },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServerService(servers, nil, &memoryKnownHostStorage{hosts: map[int]entity.KnownHost{}})
+
+			var server entity.Server
+
+			for _, stored := range servers {
+				if stored.Id == tt.id {
+					server = stored
+				}
+			}
+
+			data := testServerData(server)
+			tt.update(&data)
+
+			_, err := s.Update(context.Background(), tt.id, data)
+
+			if tt.wantErr && !errors.As(err, &ErrValidation{}) {
+				t.Errorf("Update() error = %v, want ErrValidation", err)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestServerServiceUpdateToLocalChecksLocations(t *testing.T) {
+	server := testSshServer(1, "web")
+	local := ServerData{Name: "web", Kind: entity.ServerKindLocal}
+
+	tests := []struct {
+		name      string
+		locations []entity.LogLocation
+		data      ServerData
+		wantErr   bool
+	}{
+		{
+			name:      "locations inside of local roots",
+			locations: []entity.LogLocation{{ServerId: 1, Kind: entity.LogLocationKindFile, Path: "/var/log/nginx/*.log"}},
+			data:      local,
+		},
+		{
+			name:      "location outside of local roots",
+			locations: []entity.LogLocation{{ServerId: 1, Kind: entity.LogLocationKindFile, Path: "/etc/shadow"}},
+			data:      local,
+			wantErr:   true,
+		},
+		{
+			name:      "location escaping local roots",
+			locations: []entity.LogLocation{{ServerId: 1, Kind: entity.LogLocationKindFile, Path: "/var/log/../../etc/shadow"}},
+			data:      local,
+			wantErr:   true,
+		},
+		{
+			name:      "location of another server",
+			locations: []entity.LogLocation{{ServerId: 2, Kind: entity.LogLocationKindFile, Path: "/etc/shadow"}},
+			data:      local,
+		},
+		{
+			name:      "ssh server is not restricted",
+			locations: []entity.LogLocation{{ServerId: 1, Kind: entity.LogLocationKindFile, Path: "/etc/shadow"}},
+			data:      testServerData(server),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServerService([]entity.Server{server}, tt.locations, &memoryKnownHostStorage{hosts: map[int]entity.KnownHost{}})
+
+			_, err := s.Update(context.Background(), server.Id, tt.data)
+
+			if tt.wantErr && !errors.As(err, &ErrValidation{}) {
+				t.Errorf("Update() error = %v, want ErrValidation", err)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestServerServiceCreateConnectionOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    ServerData
+		want    ServerResponse
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			data: ServerData{Name: "web", Host: "web.example.com", CredentialId: 1},
+			want: ServerResponse{Kind: entity.ServerKindSsh, Port: 22, ConnectTimeout: 10, KeepAliveCountMax: 3, Transport: entity.ServerTransportAuto},
+		},
+		{
+			name: "custom options",
+			data: ServerData{Name: "web", Host: "10.0.0.5", Port: 2222, Username: "deploy", ConnectTimeout: 30, KeepAliveInterval: 15, KeepAliveCountMax: 5, CredentialId: 1},
+			want: ServerResponse{Kind: entity.ServerKindSsh, Port: 2222, Username: "deploy", ConnectTimeout: 30, KeepAliveInterval: 15, KeepAliveCountMax: 5, Transport: entity.ServerTransportAuto},
+		},
+		{name: "port out of range", data: ServerData{Name: "web", Host: "web.example.com", Port: 70000, CredentialId: 1}, wantErr: true},
+		{name: "username with host", data: ServerData{Name: "web", Host: "web.example.com", Username: "deploy@web", CredentialId: 1}, wantErr: true},
+		{name: "connect timeout too long", data: ServerData{Name: "web", Host: "web.example.com", ConnectTimeout: 301, CredentialId: 1}, wantErr: true},
+		{name: "negative keepalive interval", data: ServerData{Name: "web", Host: "web.example.com", KeepAliveInterval: -1, CredentialId: 1}, wantErr: true},
+		{name: "malformed host", data: ServerData{Name: "web", Host: "web example", CredentialId: 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestServerService(nil, nil, &memoryKnownHostStorage{}).Create(context.Background(), tt.data)
+
+			var validationErr ErrValidation
+
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("Create() error = %v, want validation error %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			options := ServerResponse{
+				Kind:              got.Kind,
+				Port:              got.Port,
+				Username:          got.Username,
+				ConnectTimeout:    got.ConnectTimeout,
+				KeepAliveInterval: got.KeepAliveInterval,
+				KeepAliveCountMax: got.KeepAliveCountMax,
+				Transport:         got.Transport,
+			}
+
+			if !reflect.DeepEqual(options, tt.want) {
+				t.Errorf("Create() options = %+v, want %+v", options, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerServiceDeleteById(t *testing.T) {
+	jump := testSshServer(1, "bastion")
+	target := withJumpServer(testSshServer(2, "web"), jump.Id)
+
+	tests := []struct {
+		name            string
+		id              int
+		wantErr         error
+		wantInvalidated []int
+	}{
+		{name: "server without dependents", id: target.Id, wantInvalidated: []int{target.Id}},
+		{name: "jump server", id: jump.Id, wantErr: ErrServerInUse{Servers: []ServerReference{{Id: target.Id, Name: target.Name}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServerService([]entity.Server{jump, target}, nil, &memoryKnownHostStorage{})
+
+			if err := s.DeleteById(context.Background(), tt.id); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("DeleteById() error = %#v, want %#v", err, tt.wantErr)
+			}
+
+			if _, exists := s.storage.(*memoryServerStorage).servers[tt.id]; exists != (tt.wantErr != nil) {
+				t.Errorf("DeleteById() kept server = %v, want %v", exists, tt.wantErr != nil)
+			}
+
+			if invalidated := s.connections.(*recordingConnections).invalidated; !reflect.DeepEqual(invalidated, tt.wantInvalidated) {
+				t.Errorf("DeleteById() invalidated servers %v, want %v", invalidated, tt.wantInvalidated)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// An sftpLogReader is a LogReader which uses sftp subsystem, so it works on hosts where command execution is forbidden
+type sftpLogReader struct {
+	client  *sftp.Client
+	session *ssh.Session
+	release func()
+	done    chan struct{}
+}
+
+// newSFTPLogReader starts sftp subsystem in new ssh session, the session is closed when the ctx is done
+func newSFTPLogReader(ctx context.Context, client *sessionClient) (*sftpLogReader, error) {
+	session, release, err := client.NewSession(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not open ssh session: %w", err)
+	}
+
+	sftpClient, err := startSFTP(session)
+
+	if err != nil {
+		session.Close()
+		release()
+		return nil, fmt.Errorf("can not start sftp subsystem: %w", err)
+	}
+
+	r := &sftpLogReader{client: sftpClient, session: session, release: release, done: make(chan struct{})}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			sftpClient.Close()
+		case <-r.done:
+		}
+	}()
+
+	return r, nil
+}
+
+// Glob method returns sorted paths of regular files matched by the pattern
+func (r *sftpLogReader) Glob(_ context.Context, pattern string) ([]string, error) {
+	matches, err := r.client.Glob(pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not list files matched by %s: %w", pattern, err)
+	}
+
+	var files []string
+
+	for _, match := range matches {
+		info, err := r.client.Lstat(match)
+
+		if err != nil {
+			return nil, fmt.Errorf("can not stat %s: %w", match, err)
+		}
+
+		if info.Mode().IsRegular() {
+			files = append(files, match)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Open method returns content of the file, the returned reader must be closed
+func (r *sftpLogReader) Open(_ context.Context, path string) (io.ReadCloser, error) {
+	file, err := r.client.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (r *sftpLogReader) Transport() string {
+	return entity.ServerTransportSftp
+}
+
+// Close method closes sftp session
+func (r *sftpLogReader) Close() error {
+	defer r.release()
+	defer r.session.Close()
+
+	close(r.done)
+
+	return r.client.Close()
+}
+
+// startSFTP requests sftp subsystem in the session and returns client talking to it over stdin and stdout of the session
+func startSFTP(session *ssh.Session) (*sftp.Client, error) {
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, err
+	}
+
+	pw, err := session.StdinPipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := session.StdoutPipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sftp.NewClientPipe(pr, pw)
+}
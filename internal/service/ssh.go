@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// ErrConnectionFailed is returned when logman can not establish ssh connection with a Server
+var ErrConnectionFailed = errors.New("connection to server failed")
+
+// ErrAgentSocketNotAllowed is returned when agent Credential points to ssh-agent socket which is not allowed by configuration
+var ErrAgentSocketNotAllowed = errors.New("ssh-agent socket is not allowed")
+
+// A SSHConnector opens ssh connections to Server hosts using the linked Credential,
+// servers behind jump hosts are reached through the chain of ssh tunnels like openssh ProxyJump does
+type SSHConnector struct {
+	servers      ServerStorager
+	credentials  CredentialStorager
+	knownHosts   KnownHostStorager
+	agentSockets []string
+	l            Logger
+}
+
+func NewSSHConnector(servers ServerStorager, credentials CredentialStorager, knownHosts KnownHostStorager, agentSockets []string, l Logger) *SSHConnector {
+	return &SSHConnector{
+		servers:      servers,
+		credentials:  credentials,
+		knownHosts:   knownHosts,
+		agentSockets: agentSockets,
+		l:            l,
+	}
+}
+
+// Connect method opens new ssh client to the Server, the caller is responsible for closing the client,
+// closing the client closes tunnels through jump hosts as well
+func (c *SSHConnector) Connect(ctx context.Context, server entity.Server) (*ssh.Client, error) {
+	return c.connect(ctx, server, false)
+}
+
+// connect method opens ssh client to the Server, in dry run mode host keys are verified but never pinned
+func (c *SSHConnector) connect(ctx context.Context, server entity.Server, dryRun bool) (*ssh.Client, error) {
+	jumps, err := resolveJumpChain(ctx, c.servers, server)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
+	}
+
+	var (
+		client *ssh.Client
+		opened []*ssh.Client
+	)
+
+	for _, hop := range append(jumps, server) {
+		client, err = c.connectHop(ctx, hop, client, dryRun)
+
+		if err != nil {
+			closeSSHClients(opened)
+			return nil, fmt.Errorf("%w: %s: %w", ErrConnectionFailed, hop.Name, err)
+		}
+
+		opened = append(opened, client)
+	}
+
+	if len(opened) > 1 {
+		go func() {
+			client.Wait()
+			closeSSHClients(opened[:len(opened)-1])
+		}()
+	}
+
+	return client, nil
+}
+
+// connectHop method opens ssh client to the server, the connection is tunneled through via client when it is not nil
+func (c *SSHConnector) connectHop(ctx context.Context, server entity.Server, via *ssh.Client, dryRun bool) (*ssh.Client, error) {
+	credential, err := c.credentials.GetById(ctx, server.CredentialId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during Credential search by id: %w", err)
+	}
+
+	if credential == nil {
+		return nil, fmt.Errorf("credential with id %d not found", server.CredentialId)
+	}
+
+	verifier := newHostKeyVerifier(ctx, c.knownHosts, server.Id, dryRun)
+
+	cfg, closeAuth, err := c.clientConfig(server, *credential, verifier)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeAuth()
+
+	addr := serverAddr(server)
+
+	dial := (&net.Dialer{Timeout: cfg.Timeout}).DialContext
+
+	if via != nil {
+		dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+			return via.Dial(network, addr)
+		}
+	}
+
+	client, err := dialSSH(ctx, dial, addr, cfg)
+
+	if verifier.err != nil {
+		return nil, verifier.err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if server.KeepAliveInterval > 0 {
+		go keepAlive(client, time.Duration(server.KeepAliveInterval)*time.Second, server.KeepAliveCountMax)
+	}
+
+	return client, nil
+}
+
+// clientConfig method builds ssh config for the server and credential, the returned func releases resources used for authentication
+func (c *SSHConnector) clientConfig(server entity.Server, credential entity.Credential, verifier *hostKeyVerifier) (*ssh.ClientConfig, func(), error) {
+	auth, closeAuth, err := sshAuthMethods(credential, c.agentSockets)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	username := server.Username
+
+	if username == "" {
+		username, err = defaultSSHUsername()
+
+		if err != nil {
+			closeAuth()
+			return nil, nil, err
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: verifier.Verify,
+		Timeout:         connectTimeout(server),
+	}, closeAuth, nil
+}
+
+// sshAuthMethods returns auth methods of the credential kind, the returned func closes ssh-agent connection,
+// agent credentials may connect only to the allowed agent sockets
+func sshAuthMethods(credential entity.Credential, agentSockets []string) ([]ssh.AuthMethod, func(), error) {
+	noop := func() {}
+
+	switch credential.Kind {
+	case entity.CredentialKindKey:
+		key, err := os.ReadFile(string(credential.Path))
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("can not read private key: %w", err)
+		}
+
+		signer, err := credentialSigner(key, credential)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, noop, nil
+	case entity.CredentialKindInlineKey:
+		signer, err := credentialSigner([]byte(credential.PrivateKey), credential)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, noop, nil
+	case entity.CredentialKindPassword:
+		password := credential.Password
+
+		// servers with PasswordAuthentication disabled often still accept password via keyboard-interactive
+		challenge := func(_, _ string, questions []string, _ []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+
+			for i := range answers {
+				answers[i] = password
+			}
+
+			return answers, nil
+		}
+
+		return []ssh.AuthMethod{ssh.Password(password), ssh.KeyboardInteractive(challenge)}, noop, nil
+	case entity.CredentialKindAgent:
+		if !agentSocketAllowed(agentSockets, credential.AgentSocket) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrAgentSocketNotAllowed, credential.AgentSocket)
+		}
+
+		conn, err := net.Dial("unix", credential.AgentSocket)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("can not connect to ssh-agent: %w", err)
+		}
+
+		return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, func() { conn.Close() }, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported credential kind %q", credential.Kind)
+}
+
+// agentSocketAllowed shows whether logman may connect to the ssh-agent socket, without configured sockets
+// only the agent of logman itself from SSH_AUTH_SOCK is allowed
+func agentSocketAllowed(agentSockets []string, socket string) bool {
+	if len(agentSockets) == 0 {
+		agentSockets = []string{os.Getenv("SSH_AUTH_SOCK")}
+	}
+
+	for _, allowed := range agentSockets {
+		if allowed != "" && filepath.Clean(allowed) == filepath.Clean(socket) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// credentialSigner returns signer of the private key, the certificate of the credential is offered instead of
+// the public key when it is set
+func credentialSigner(pemBytes []byte, credential entity.Credential) (ssh.Signer, error) {
+	signer, err := parsePrivateKey(pemBytes, credential.Passphrase)
+
+	if err != nil || credential.Certificate == "" {
+		return signer, err
+	}
+
+	return certificateSigner(signer, credential.Certificate)
+}
+
+// parsePrivateKey parses pem encoded private key, the passphrase is used only when it is not empty
+func parsePrivateKey(pemBytes []byte, passphrase string) (ssh.Signer, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(pemBytes)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can not parse private key: %w", err)
+	}
+
+	return signer, nil
+}
+
+// dialSSH opens connection to the addr by the dial func and makes ssh handshake over it, the ctx cancels only dialing
+func dialSSH(ctx context.Context, dial func(ctx context.Context, network, addr string) (net.Conn, error), addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := dial(ctx, "tcp", addr)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not dial %s: %w", addr, err)
+	}
+
+	if cfg.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(cfg.Timeout))
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", addr, err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// keepAlive sends keepalive requests every interval like openssh ServerAliveInterval does,
+// the client is closed when countMax requests in a row are not answered
+func keepAlive(client *ssh.Client, interval time.Duration, countMax int) {
+	done := make(chan struct{})
+
+	go func() {
+		client.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		result := make(chan error, 1)
+
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			result <- err
+		}()
+
+		var err error
+
+		select {
+		case err = <-result:
+		case <-time.After(interval):
+			err = errors.New("keepalive timeout")
+		case <-done:
+			return
+		}
+
+		if err == nil {
+			missed = 0
+			continue
+		}
+
+		if missed++; missed >= countMax {
+			client.Close()
+			return
+		}
+	}
+}
+
+// serverAddr returns ssh address of the Server, default port is used when the port is not set
+func serverAddr(server entity.Server) string {
+	port := server.Port
+
+	if port == 0 {
+		port = entity.DefaultServerPort
+	}
+
+	return net.JoinHostPort(server.Host, strconv.Itoa(port))
+}
+
+// connectTimeout returns timeout of establishing connection to the Server
+func connectTimeout(server entity.Server) time.Duration {
+	timeout := server.ConnectTimeout
+
+	if timeout == 0 {
+		timeout = entity.DefaultConnectTimeout
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// defaultSSHUsername returns name of the user who runs logman, like openssh does when user is not specified
+func defaultSSHUsername() (string, error) {
+	u, err := user.Current()
+
+	if err != nil {
+		return "", fmt.Errorf("can not detect current user: %w", err)
+	}
+
+	return u.Username, nil
+}
+
+// closeSSHClients closes clients in reverse order, so tunneled connections are closed before their jump hosts
+func closeSSHClients(clients []*ssh.Client) {
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
+}
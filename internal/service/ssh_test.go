@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestServerAddr(t *testing.T) {
+	tests := []struct {
+		server entity.Server
+		want   string
+	}{
+		{server: entity.Server{Host: "web.example.com", Port: 2222}, want: "web.example.com:2222"},
+		{server: entity.Server{Host: "10.0.0.5"}, want: "10.0.0.5:22"},
+		{server: entity.Server{Host: "2001:db8::1", Port: 22}, want: "[2001:db8::1]:22"},
+	}
+
+	for _, tt := range tests {
+		if got := serverAddr(tt.server); got != tt.want {
+			t.Errorf("serverAddr(%s) = %s, want %s", tt.server.Host, got, tt.want)
+		}
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	tests := []struct {
+		timeout int
+		want    time.Duration
+	}{
+		{timeout: 0, want: 10 * time.Second},
+		{timeout: 30, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := connectTimeout(entity.Server{ConnectTimeout: tt.timeout}); got != tt.want {
+			t.Errorf("connectTimeout(%d) = %s, want %s", tt.timeout, got, tt.want)
+		}
+	}
+}
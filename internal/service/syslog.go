@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const rfc3164TimeLayout = "Jan _2 15:04:05"
+
+// syslogFacilities are names of syslog facility codes
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var errNotSyslog = errors.New("line is not a syslog message")
+
+// A rfc3164LogParser parses BSD syslog lines like "Oct 18 09:05:12 host prog[pid]: msg" with optional priority,
+// timestamps without year belong to the latest year which does not put them more than a day after now,
+// high precision RFC 3339 timestamps written by rsyslog are supported as well
+type rfc3164LogParser struct {
+	now time.Time
+}
+
+func (p rfc3164LogParser) Parse(line string) (LogEntry, error) {
+	fields := map[string]any{}
+
+	rest, level, err := parseSyslogPriority(line, fields)
+
+	if err != nil {
+		return LogEntry{}, err
+	}
+
+	timestamp, rest, ok := p.timestamp(rest)
+
+	if !ok {
+		return LogEntry{}, errNotSyslog
+	}
+
+	hostname, rest, _ := strings.Cut(rest, " ")
+
+	if hostname == "" {
+		return LogEntry{}, errNotSyslog
+	}
+
+	fields["hostname"] = hostname
+
+	message := rest
+
+	if app, procid, tagged, ok := parseSyslogTag(rest); ok {
+		fields["app_name"] = app
+		message = tagged
+
+		if procid != "" {
+			fields["procid"] = procid
+		}
+	}
+
+	return LogEntry{Timestamp: &timestamp, Level: level, Message: message, Fields: fields, Raw: line}, nil
+}
+
+// timestamp method returns timestamp at the beginning of s and the rest of s after it
+func (p rfc3164LogParser) timestamp(s string) (time.Time, string, bool) {
+	if len(s) > len(rfc3164TimeLayout) && s[len(rfc3164TimeLayout)] == ' ' {
+		if t, err := time.ParseInLocation(rfc3164TimeLayout, s[:len(rfc3164TimeLayout)], time.Local); err == nil {
+			return syslogYear(t, p.now), s[len(rfc3164TimeLayout)+1:], true
+		}
+	}
+
+	value, rest, _ := strings.Cut(s, " ")
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, rest, true
+	}
+
+	return time.Time{}, "", false
+}
+
+// syslogYear sets the year of timestamp parsed without year, timestamps more than a day after now
+// were written in the previous year, February 29 is kept in the latest leap year
+func syslogYear(t, now time.Time) time.Time {
+	for year := now.Year(); ; year-- {
+		stamp := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+		// February 29 of a common year is normalized to March 1
+		if stamp.Day() == t.Day() && !stamp.After(now.Add(24*time.Hour)) {
+			return stamp
+		}
+	}
+}
+
+// parseSyslogTag splits "prog[pid]: msg" and "prog: msg" messages to the program, process id and the rest of message
+func parseSyslogTag(s string) (string, string, string, bool) {
+	end := strings.IndexAny(s, ":[ ")
+
+	if end <= 0 || s[end] == ' ' {
+		return "", "", "", false
+	}
+
+	app, procid, rest := s[:end], "", s[end:]
+
+	if rest[0] == '[' {
+		closing := strings.IndexByte(rest, ']')
+
+		if closing == -1 {
+			return "", "", "", false
+		}
+
+		procid, rest = rest[1:closing], rest[closing+1:]
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", "", false
+	}
+
+	return app, procid, strings.TrimPrefix(rest[1:], " "), true
+}
+
+// A rfc5424LogParser parses syslog lines of RFC 5424 with optional priority, structured data elements
+// are kept in the structured_data field as maps of parameters by element id
+type rfc5424LogParser struct{}
+
+func (rfc5424LogParser) Parse(line string) (LogEntry, error) {
+	fields := map[string]any{}
+
+	rest, level, err := parseSyslogPriority(line, fields)
+
+	if err != nil {
+		return LogEntry{}, err
+	}
+
+	version, rest, _ := strings.Cut(rest, " ")
+
+	if _, err := strconv.Atoi(version); err != nil {
+		return LogEntry{}, errNotSyslog
+	}
+
+	entry := LogEntry{Level: level, Raw: line}
+
+	for _, name := range []string{"timestamp", "hostname", "app_name", "procid", "msgid"} {
+		var value string
+
+		value, rest, _ = strings.Cut(rest, " ")
+
+		switch {
+		case value == "":
+			return LogEntry{}, errNotSyslog
+		case value == "-":
+			continue
+		case name == "timestamp":
+			t, err := time.Parse(time.RFC3339Nano, value)
+
+			if err != nil {
+				return LogEntry{}, fmt.Errorf("invalid syslog timestamp: %w", err)
+			}
+
+			entry.Timestamp = &t
+		default:
+			fields[name] = value
+		}
+	}
+
+	data, message, err := parseStructuredData(rest)
+
+	if err != nil {
+		return LogEntry{}, err
+	}
+
+	if data != nil {
+		fields["structured_data"] = data
+	}
+
+	entry.Message = strings.TrimPrefix(message, "\ufeff")
+	entry.Fields = fields
+
+	return entry, nil
+}
+
+// parseStructuredData returns structured data elements at the beginning of s and the message after them
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if s == "" || s[0] == '-' {
+		return nil, strings.TrimPrefix(strings.TrimPrefix(s, "-"), " "), nil
+	}
+
+	data := map[string]map[string]string{}
+
+	for strings.HasPrefix(s, "[") {
+		end := strings.IndexAny(s, " ]")
+
+		if end <= 1 {
+			return nil, "", errors.New("invalid structured data element id")
+		}
+
+		params := map[string]string{}
+		data[s[1:end]] = params
+		s = s[end:]
+
+		for strings.HasPrefix(s, " ") {
+			name, value, ok := strings.Cut(s[1:], `="`)
+
+			if !ok || name == "" {
+				return nil, "", errors.New("invalid structured data parameter")
+			}
+
+			var b strings.Builder
+
+			i := 0
+
+			for ; i < len(value) && value[i] != '"'; i++ {
+				// only quote, backslash and closing bracket are escaped, other backslashes are kept
+				if value[i] == '\\' && i+1 < len(value) && strings.IndexByte(`"\]`, value[i+1]) != -1 {
+					i++
+				}
+
+				b.WriteByte(value[i])
+			}
+
+			if i == len(value) {
+				return nil, "", errors.New("unterminated structured data parameter")
+			}
+
+			params[name] = b.String()
+			s = value[i+1:]
+		}
+
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", errors.New("unterminated structured data element")
+		}
+
+		s = s[1:]
+	}
+
+	if s != "" && s[0] != ' ' {
+		return nil, "", errors.New("invalid structured data")
+	}
+
+	return data, strings.TrimPrefix(s, " "), nil
+}
+
+// parseSyslogPriority parses optional "<PRI>" prefix of the line, the facility is added to fields
+// and the severity is returned as a level
+func parseSyslogPriority(line string, fields map[string]any) (string, string, error) {
+	if !strings.HasPrefix(line, "<") {
+		return line, "", nil
+	}
+
+	value, rest, ok := strings.Cut(line[1:], ">")
+
+	if !ok {
+		return "", "", errNotSyslog
+	}
+
+	priority, err := strconv.Atoi(value)
+
+	if err != nil || priority < 0 || priority >= len(syslogFacilities)*8 {
+		return "", "", fmt.Errorf("invalid syslog priority %q", value)
+	}
+
+	fields["facility"] = syslogFacilities[priority/8]
+
+	return rest, journalLevels[strconv.Itoa(priority%8)], nil
+}
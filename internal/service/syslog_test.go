@@ -0,0 +1,196 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRfc3164LogParser(t *testing.T) {
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		line      string
+		timestamp time.Time
+		level     string
+		message   string
+		fields    map[string]any
+		wantErr   bool
+	}{
+		{
+			name:      "priority and tag with pid",
+			line:      "<38>Oct 18 09:05:12 web sshd[1234]: Accepted publickey for root",
+			timestamp: time.Date(2026, time.October, 18, 9, 5, 12, 0, time.Local),
+			level:     "info",
+			message:   "Accepted publickey for root",
+			fields:    map[string]any{"facility": "auth", "hostname": "web", "app_name": "sshd", "procid": "1234"},
+		},
+		{
+			name:      "single digit day without priority",
+			line:      "Oct  3 01:00:00 db cron: job started",
+			timestamp: time.Date(2026, time.October, 3, 1, 0, 0, 0, time.Local),
+			message:   "job started",
+			fields:    map[string]any{"hostname": "db", "app_name": "cron"},
+		},
+		{
+			name:      "untagged message",
+			line:      "<0>Oct 18 09:05:12 web kernel panic now",
+			timestamp: time.Date(2026, time.October, 18, 9, 5, 12, 0, time.Local),
+			level:     "emerg",
+			message:   "kernel panic now",
+			fields:    map[string]any{"facility": "kern", "hostname": "web"},
+		},
+		{
+			name:      "rsyslog high precision timestamp",
+			line:      "2026-10-18T09:05:12.123456+02:00 web app[7]: done",
+			timestamp: time.Date(2026, time.October, 18, 7, 5, 12, 123456000, time.UTC),
+			message:   "done",
+			fields:    map[string]any{"hostname": "web", "app_name": "app", "procid": "7"},
+		},
+		{
+			name:    "invalid priority",
+			line:    "<192>Oct 18 09:05:12 web app: msg",
+			wantErr: true,
+		},
+		{
+			name:    "missing timestamp",
+			line:    "web app: msg",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := rfc3164LogParser{now: now}.Parse(tt.line)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !entry.Timestamp.Equal(tt.timestamp) {
+				t.Errorf("Parse() timestamp = %v, want %v", entry.Timestamp, tt.timestamp)
+			}
+
+			if entry.Level != tt.level || entry.Message != tt.message || !reflect.DeepEqual(entry.Fields, tt.fields) {
+				t.Errorf("Parse() = %+v", entry)
+			}
+		})
+	}
+}
+
+func TestSyslogYear(t *testing.T) {
+	newYear := time.Date(2026, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now   time.Time
+		month time.Month
+		day   int
+		want  int
+	}{
+		{now: newYear, month: time.January, day: 1, want: 2026},
+		{now: newYear, month: time.January, day: 2, want: 2026},
+		{now: newYear, month: time.January, day: 3, want: 2025},
+		{now: newYear, month: time.December, day: 31, want: 2025},
+		{now: newYear, month: time.February, day: 29, want: 2024},
+		{now: time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC), month: time.February, day: 29, want: 2024},
+		{now: time.Date(2028, time.March, 1, 10, 0, 0, 0, time.UTC), month: time.February, day: 29, want: 2028},
+		{now: time.Date(2028, time.February, 28, 10, 0, 0, 0, time.UTC), month: time.February, day: 29, want: 2028},
+		{now: time.Date(2028, time.February, 27, 10, 0, 0, 0, time.UTC), month: time.February, day: 29, want: 2024},
+		{now: time.Date(2101, time.March, 1, 10, 0, 0, 0, time.UTC), month: time.February, day: 29, want: 2096},
+	}
+
+	for _, tt := range tests {
+		got := syslogYear(time.Date(0, tt.month, tt.day, 9, 0, 0, 0, time.UTC), tt.now)
+
+		if got.Year() != tt.want || got.Month() != tt.month || got.Day() != tt.day {
+			t.Errorf("syslogYear(%s %d) = %v, want year %d", tt.month, tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestRfc5424LogParser(t *testing.T) {
+	line := `<165>1 2026-10-18T09:05:12.003Z api.example.com billing 42 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication"][meta seq="1"] ` + "\ufeff" + `charge failed`
+
+	entry, err := rfc5424LogParser{}.Parse(line)
+
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string]any{
+		"facility": "local4",
+		"hostname": "api.example.com",
+		"app_name": "billing",
+		"procid":   "42",
+		"msgid":    "ID47",
+		"structured_data": map[string]map[string]string{
+			"exampleSDID@32473": {"iut": "3", "eventSource": `App"lication`},
+			"meta":              {"seq": "1"},
+		},
+	}
+
+	if entry.Level != "notice" || entry.Message != "charge failed" || !reflect.DeepEqual(entry.Fields, want) {
+		t.Errorf("Parse() = %+v", entry)
+	}
+
+	if entry.Timestamp == nil || !entry.Timestamp.Equal(time.Date(2026, time.October, 18, 9, 5, 12, 3000000, time.UTC)) {
+		t.Errorf("Parse() timestamp = %v", entry.Timestamp)
+	}
+
+	entry, err = rfc5424LogParser{}.Parse("<14>1 - - - - - - started")
+
+	if err != nil {
+		t.Fatalf("Parse() of nil values error = %v", err)
+	}
+
+	if entry.Timestamp != nil || entry.Message != "started" || !reflect.DeepEqual(entry.Fields, map[string]any{"facility": "user"}) {
+		t.Errorf("Parse() of nil values = %+v", entry)
+	}
+
+	if _, err := (rfc5424LogParser{}).Parse("<14>x 2026-10-18T09:05:12Z host app - - - msg"); err == nil {
+		t.Error("Parse() accepted line without version")
+	}
+}
+
+func TestParseStructuredData(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		data    map[string]map[string]string
+		message string
+		wantErr bool
+	}{
+		{name: "nil value", s: "- message", message: "message"},
+		{name: "nil value without message", s: "-"},
+		{name: "element without parameters", s: "[origin] msg", data: map[string]map[string]string{"origin": {}}, message: "msg"},
+		{
+			name:    "escapes",
+			s:       `[a x="q\"b\\c\]d\n"]`,
+			data:    map[string]map[string]string{"a": {"x": `q"b\c]d\n`}},
+			message: "",
+		},
+		{name: "unterminated parameter", s: `[a x="1]`, wantErr: true},
+		{name: "unterminated element", s: `[a x="1"`, wantErr: true},
+		{name: "empty id", s: `[] msg`, wantErr: true},
+		{name: "text after element", s: `[a]msg`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, message, err := parseStructuredData(tt.s)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStructuredData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (!reflect.DeepEqual(data, tt.data) || message != tt.message) {
+				t.Errorf("parseStructuredData() = %v, %q, want %v, %q", data, message, tt.data, tt.message)
+			}
+		})
+	}
+}
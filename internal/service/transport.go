@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// A LogReader reads log files of a Server, implementations differ by the way files are transferred
+type LogReader interface {
+	// Glob method returns sorted paths of regular files matched by the pattern, the pattern syntax is the one of path.Match
+	Glob(ctx context.Context, pattern string) ([]string, error)
+	// Open method returns content of the file, the returned reader must be closed
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	// Transport method returns name of the transport used by the reader
+	Transport() string
+	// Close method releases resources of the reader
+	Close() error
+}
+
+// A CommandRunner runs shell commands on the Server, readers which can not execute commands do not implement it
+type CommandRunner interface {
+	// Run method starts the cmd and returns its stdout, closing the returned reader reports failure of the cmd
+	Run(ctx context.Context, cmd string) (io.ReadCloser, error)
+}
+
+// readableBySftp reports whether every location is a set of files, so they can be read without running commands
+func readableBySftp(locations []entity.LogLocation) bool {
+	for _, location := range locations {
+		if location.Kind != entity.LogLocationKindFile {
+			return false
+		}
+	}
+
+	return true
+}
+
+// globBase splits the pattern into the longest directory without wildcards and the number of path segments after it
+func globBase(pattern string) (string, int) {
+	segments := strings.Split(path.Clean(pattern), "/")
+
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			return path.Join("/", path.Join(segments[:i]...)), len(segments) - i
+		}
+	}
+
+	return path.Dir(pattern), 1
+}
+
+// openLogReader returns LogReader of the Server transport over the client, auto transport prefers sftp
+// and falls back to exec when sftp subsystem can not be started, servers using sudo or having locations
+// which are read by commands are always read by exec
+func openLogReader(ctx context.Context, client *sessionClient, server entity.Server, locations []entity.LogLocation, sudo *sudoPrivilege, l Logger) (LogReader, error) {
+	switch server.Transport {
+	case entity.ServerTransportExec:
+		return newExecLogReader(client, sudo), nil
+	case entity.ServerTransportSftp:
+		reader, err := newSFTPLogReader(ctx, client)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
+		}
+
+		return reader, nil
+	case entity.ServerTransportAuto, "":
+		if sudo != nil || !readableBySftp(locations) {
+			return newExecLogReader(client, sudo), nil
+		}
+
+		reader, err := newSFTPLogReader(ctx, client)
+
+		if err != nil {
+			l.Info("sftp is not available, falling back to exec", slog.Int("serverId", server.Id), slog.String("error", err.Error()))
+
+			return newExecLogReader(client, sudo), nil
+		}
+
+		return reader, nil
+	}
+
+	return nil, fmt.Errorf("unsupported transport %q", server.Transport)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestOpenLogReader(t *testing.T) {
+	files := []entity.LogLocation{{Kind: entity.LogLocationKindFile, Path: "/var/log/*.log"}}
+	journal := append([]entity.LogLocation{{Kind: entity.LogLocationKindJournald}}, files...)
+
+	tests := []struct {
+		name      string
+		transport string
+		locations []entity.LogLocation
+		sudo      *sudoPrivilege
+		want      string
+		wantErr   bool
+	}{
+		{name: "exec transport", transport: entity.ServerTransportExec, locations: files, want: entity.ServerTransportExec},
+		{name: "auto transport with sudo", transport: entity.ServerTransportAuto, locations: files, sudo: &sudoPrivilege{}, want: entity.ServerTransportExec},
+		{name: "auto transport with commands", transport: entity.ServerTransportAuto, locations: journal, want: entity.ServerTransportExec},
+		{name: "unsupported transport", transport: "scp", locations: files, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := entity.Server{Id: 1, Transport: tt.transport}
+
+			reader, err := openLogReader(context.Background(), nil, server, tt.locations, tt.sudo, discardLogger{})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openLogReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && reader.Transport() != tt.want {
+				t.Errorf("openLogReader() transport = %s, want %s", reader.Transport(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadableBySftp(t *testing.T) {
+	tests := []struct {
+		name  string
+		kinds []string
+		want  bool
+	}{
+		{name: "no locations", want: true},
+		{name: "files", kinds: []string{entity.LogLocationKindFile, entity.LogLocationKindFile}, want: true},
+		{name: "journal", kinds: []string{entity.LogLocationKindFile, entity.LogLocationKindJournald}},
+		{name: "docker", kinds: []string{entity.LogLocationKindDocker}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var locations []entity.LogLocation
+
+			for _, kind := range tt.kinds {
+				locations = append(locations, entity.LogLocation{Kind: kind})
+			}
+
+			if got := readableBySftp(locations); got != tt.want {
+				t.Errorf("readableBySftp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
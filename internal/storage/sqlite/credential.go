@@ -9,17 +9,27 @@ import (
 	"github.com/krasilnikovm/logman/internal/entity"
 )
 
+// A CredentialStorage contains methods for communication with Credential entity,
+// secret fields of the Credential are stored encrypted by the EnvelopeCipher
 type CredentialStorage struct {
 	connStr string
+	cipher  *EnvelopeCipher
 }
 
-func NewCredentialStorage(connStr string) *CredentialStorage {
+func NewCredentialStorage(connStr string, cipher *EnvelopeCipher) *CredentialStorage {
 	return &CredentialStorage{
 		connStr: connStr,
+		cipher:  cipher,
 	}
 }
 
 func (c *CredentialStorage) Create(ctx context.Context, credential *entity.Credential) error {
+	secrets, dataKey, err := c.sealSecrets(credential)
+
+	if err != nil {
+		return err
+	}
+
 	db, err := sql.Open(DriverName, c.connStr)
 
 	if err != nil {
@@ -30,7 +40,7 @@ func (c *CredentialStorage) Create(ctx context.Context, credential *entity.Crede
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"INSERT INTO credentials (name, path, created_at, updated_at) VALUES(?, ?,?,?)",
+		"INSERT INTO credentials (name, kind, path, passphrase, password, agent_socket, private_key, data_key, certificate, created_at, updated_at) VALUES(?,?,?,?,?,?,?,?,?,?,?)",
 	)
 
 	if err != nil {
@@ -42,7 +52,14 @@ func (c *CredentialStorage) Create(ctx context.Context, credential *entity.Crede
 	result, err := stmt.ExecContext(
 		ctx,
 		credential.Name,
+		credential.Kind,
 		credential.Path,
+		secrets.passphrase,
+		secrets.password,
+		credential.AgentSocket,
+		secrets.privateKey,
+		dataKey,
+		credential.Certificate,
 		credential.CreatedAt,
 		credential.UpdatedAt,
 	)
@@ -73,7 +90,7 @@ func (c *CredentialStorage) GetById(ctx context.Context, id int) (*entity.Creden
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"SELECT id, name, path, created_at, updated_at FROM credentials WHERE id = ?",
+		"SELECT id, name, kind, path, passphrase, password, agent_socket, private_key, data_key, certificate, created_at, updated_at FROM credentials WHERE id = ?",
 	)
 
 	if err != nil {
@@ -84,12 +101,22 @@ func (c *CredentialStorage) GetById(ctx context.Context, id int) (*entity.Creden
 
 	row := stmt.QueryRowContext(ctx, id)
 
-	var credential entity.Credential
+	var (
+		credential entity.Credential
+		dataKey    string
+	)
 
 	err = row.Scan(
 		&credential.Id,
 		&credential.Name,
+		&credential.Kind,
 		&credential.Path,
+		&credential.Passphrase,
+		&credential.Password,
+		&credential.AgentSocket,
+		&credential.PrivateKey,
+		&dataKey,
+		&credential.Certificate,
 		&credential.CreatedAt,
 		&credential.UpdatedAt,
 	)
@@ -102,9 +129,15 @@ func (c *CredentialStorage) GetById(ctx context.Context, id int) (*entity.Creden
 		return nil, fmt.Errorf("error during scanning row: %w", err)
 	}
 
+	if err := c.openSecrets(&credential, dataKey); err != nil {
+		return nil, err
+	}
+
 	return &credential, nil
 }
 
+// GetList method returns credentials without secret fields, so the list does not depend on the master key
+// and one undecryptable credential does not break it, secrets are read by GetById only
 func (c *CredentialStorage) GetList(ctx context.Context, page, limit int) ([]*entity.Credential, error) {
 	db, err := sql.Open(DriverName, c.connStr)
 
@@ -116,7 +149,7 @@ func (c *CredentialStorage) GetList(ctx context.Context, page, limit int) ([]*en
 
 	rows, err := db.QueryContext(
 		ctx,
-		"SELECT id, name, path, created_at, updated_at FROM credentials ORDER BY id DESC LIMIT ? OFFSET ?;",
+		"SELECT id, name, kind, path, agent_socket, certificate, created_at, updated_at FROM credentials ORDER BY id DESC LIMIT ? OFFSET ?;",
 		limit,
 		(page-1)*limit,
 	)
@@ -135,7 +168,10 @@ func (c *CredentialStorage) GetList(ctx context.Context, page, limit int) ([]*en
 		err := rows.Scan(
 			&credential.Id,
 			&credential.Name,
+			&credential.Kind,
 			&credential.Path,
+			&credential.AgentSocket,
+			&credential.Certificate,
 			&credential.CreatedAt,
 			&credential.UpdatedAt,
 		)
@@ -179,7 +215,46 @@ func (c *CredentialStorage) DeleteById(ctx context.Context, id int) error {
 	return nil
 }
 
+// A ReassignAndDeleteById method moves Servers of the Credential to another Credential and deletes it in one transaction
+func (c *CredentialStorage) ReassignAndDeleteById(ctx context.Context, id, reassignTo int) error {
+	db, err := sql.Open(DriverName, c.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("can not begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE servers SET credential_id = ? WHERE credential_id = ?;", reassignTo, id); err != nil {
+		return fmt.Errorf("error during reassigning servers: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM credentials WHERE id = ?;", id); err != nil {
+		return fmt.Errorf("error during executing query: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (c *CredentialStorage) Update(ctx context.Context, credential *entity.Credential) error {
+	secrets, dataKey, err := c.sealSecrets(credential)
+
+	if err != nil {
+		return err
+	}
+
 	db, err := sql.Open(DriverName, c.connStr)
 
 	if err != nil {
@@ -190,7 +265,7 @@ func (c *CredentialStorage) Update(ctx context.Context, credential *entity.Crede
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"UPDATE credentials SET name = ?, path = ?, updated_at = ? WHERE id = ?;",
+		"UPDATE credentials SET name = ?, kind = ?, path = ?, passphrase = ?, password = ?, agent_socket = ?, private_key = ?, data_key = ?, certificate = ?, updated_at = ? WHERE id = ?;",
 	)
 
 	if err != nil {
@@ -202,7 +277,14 @@ func (c *CredentialStorage) Update(ctx context.Context, credential *entity.Crede
 	_, err = stmt.ExecContext(
 		ctx,
 		credential.Name,
+		credential.Kind,
 		credential.Path,
+		secrets.passphrase,
+		secrets.password,
+		credential.AgentSocket,
+		secrets.privateKey,
+		dataKey,
+		credential.Certificate,
 		credential.UpdatedAt,
 		credential.Id,
 	)
@@ -213,3 +295,237 @@ func (c *CredentialStorage) Update(ctx context.Context, credential *entity.Crede
 
 	return nil
 }
+
+// RotateMasterKey method re-encrypts data keys of all credentials by the next cipher in a single transaction,
+// credentials stored before encryption was enabled are encrypted as well, the method returns number of updated rows
+func (c *CredentialStorage) RotateMasterKey(ctx context.Context, next *EnvelopeCipher) (int, error) {
+	if next == nil {
+		return 0, fmt.Errorf("new %w", ErrMasterKeyMissing)
+	}
+
+	db, err := sql.Open(DriverName, c.connStr)
+
+	if err != nil {
+		return 0, fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, fmt.Errorf("can not begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, passphrase, password, private_key, data_key FROM credentials;")
+
+	if err != nil {
+		return 0, fmt.Errorf("error during executing query: %w", err)
+	}
+
+	defer rows.Close()
+
+	type rotatedRow struct {
+		id      int
+		secrets credentialSecrets
+		dataKey string
+	}
+
+	var rotated []rotatedRow
+
+	for rows.Next() {
+		var (
+			row     rotatedRow
+			dataKey string
+		)
+
+		if err := rows.Scan(&row.id, &row.secrets.passphrase, &row.secrets.password, &row.secrets.privateKey, &dataKey); err != nil {
+			return 0, fmt.Errorf("error during scanning row: %w", err)
+		}
+
+		if dataKey == "" {
+			if row.secrets.empty() {
+				continue
+			}
+
+			// the row has been stored in plain text, it gets own data key
+			plainKey, wrapped, err := next.NewDataKey()
+
+			if err != nil {
+				return 0, err
+			}
+
+			if row.secrets, err = row.secrets.encrypt(next, plainKey); err != nil {
+				return 0, err
+			}
+
+			row.dataKey = wrapped
+		} else {
+			if c.cipher == nil {
+				return 0, fmt.Errorf("current %w", ErrMasterKeyMissing)
+			}
+
+			plainKey, err := c.cipher.UnwrapDataKey(dataKey)
+
+			if err != nil {
+				return 0, fmt.Errorf("credential %d: %w", row.id, err)
+			}
+
+			if row.dataKey, err = next.WrapDataKey(plainKey); err != nil {
+				return 0, err
+			}
+		}
+
+		rotated = append(rotated, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error during reading rows: %w", err)
+	}
+
+	// updates can not be executed while rows of the same transaction are being read
+	rows.Close()
+
+	for _, row := range rotated {
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE credentials SET passphrase = ?, password = ?, private_key = ?, data_key = ? WHERE id = ?;",
+			row.secrets.passphrase,
+			row.secrets.password,
+			row.secrets.privateKey,
+			row.dataKey,
+			row.id,
+		)
+
+		if err != nil {
+			return 0, fmt.Errorf("error during executing query: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("can not commit transaction: %w", err)
+	}
+
+	return len(rotated), nil
+}
+
+// credentialSecrets contains secret columns of the credentials table
+type credentialSecrets struct {
+	passphrase string
+	password   string
+	privateKey string
+}
+
+func (s credentialSecrets) empty() bool {
+	return s.passphrase == "" && s.password == "" && s.privateKey == ""
+}
+
+func (s credentialSecrets) encrypt(cipher *EnvelopeCipher, dataKey []byte) (credentialSecrets, error) {
+	var (
+		encrypted credentialSecrets
+		err       error
+	)
+
+	if encrypted.passphrase, err = cipher.Encrypt(dataKey, "passphrase", s.passphrase); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.password, err = cipher.Encrypt(dataKey, "password", s.password); err != nil {
+		return encrypted, err
+	}
+
+	if encrypted.privateKey, err = cipher.Encrypt(dataKey, "private_key", s.privateKey); err != nil {
+		return encrypted, err
+	}
+
+	return encrypted, nil
+}
+
+func (s credentialSecrets) decrypt(cipher *EnvelopeCipher, dataKey []byte) (credentialSecrets, error) {
+	var (
+		decrypted credentialSecrets
+		err       error
+	)
+
+	if decrypted.passphrase, err = cipher.Decrypt(dataKey, "passphrase", s.passphrase); err != nil {
+		return decrypted, err
+	}
+
+	if decrypted.password, err = cipher.Decrypt(dataKey, "password", s.password); err != nil {
+		return decrypted, err
+	}
+
+	if decrypted.privateKey, err = cipher.Decrypt(dataKey, "private_key", s.privateKey); err != nil {
+		return decrypted, err
+	}
+
+	return decrypted, nil
+}
+
+// sealSecrets method returns encrypted secrets of the credential and wrapped data key,
+// credentials without secrets are stored without data key
+func (c *CredentialStorage) sealSecrets(credential *entity.Credential) (credentialSecrets, string, error) {
+	secrets := credentialSecrets{
+		passphrase: credential.Passphrase,
+		password:   credential.Password,
+		privateKey: credential.PrivateKey,
+	}
+
+	if secrets.empty() {
+		return secrets, "", nil
+	}
+
+	if c.cipher == nil {
+		return credentialSecrets{}, "", fmt.Errorf("can not store credential secrets: %w", ErrMasterKeyMissing)
+	}
+
+	plainKey, wrapped, err := c.cipher.NewDataKey()
+
+	if err != nil {
+		return credentialSecrets{}, "", err
+	}
+
+	encrypted, err := secrets.encrypt(c.cipher, plainKey)
+
+	if err != nil {
+		return credentialSecrets{}, "", err
+	}
+
+	return encrypted, wrapped, nil
+}
+
+// openSecrets method decrypts secret fields of the credential in place,
+// credentials without data key are stored in plain text and returned as is
+func (c *CredentialStorage) openSecrets(credential *entity.Credential, dataKey string) error {
+	if dataKey == "" {
+		return nil
+	}
+
+	if c.cipher == nil {
+		return fmt.Errorf("can not read credential secrets: %w", ErrMasterKeyMissing)
+	}
+
+	plainKey, err := c.cipher.UnwrapDataKey(dataKey)
+
+	if err != nil {
+		return fmt.Errorf("credential %d: %w", credential.Id, err)
+	}
+
+	secrets, err := credentialSecrets{
+		passphrase: credential.Passphrase,
+		password:   credential.Password,
+		privateKey: credential.PrivateKey,
+	}.decrypt(c.cipher, plainKey)
+
+	if err != nil {
+		return fmt.Errorf("credential %d: %w", credential.Id, err)
+	}
+
+	credential.Passphrase = secrets.passphrase
+	credential.Password = secrets.password
+	credential.PrivateKey = secrets.privateKey
+
+	return nil
+}
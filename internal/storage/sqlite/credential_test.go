@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestCredentialStorageGetListWithoutSecrets(t *testing.T) {
+	ctx := context.Background()
+	connStr := migratedDatabase(t)
+
+	credential := &entity.Credential{
+		Name:       "encrypted",
+		Kind:       entity.CredentialKindInlineKey,
+		PrivateKey: "key",
+		CreatedAt:  "2026-10-18",
+		UpdatedAt:  "2026-10-18",
+	}
+
+	if err := NewCredentialStorage(connStr, mustEnvelopeCipher(t)).Create(ctx, credential); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cipher *EnvelopeCipher
+	}{
+		{name: "without master key"},
+		{name: "with another master key", cipher: mustEnvelopeCipher(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewCredentialStorage(connStr, tt.cipher)
+
+			credentials, err := storage.GetList(ctx, 1, 10)
+
+			if err != nil {
+				t.Fatalf("GetList() error = %v", err)
+			}
+
+			if len(credentials) != 1 || credentials[0].Name != credential.Name || credentials[0].PrivateKey != "" {
+				t.Errorf("GetList() = %+v, want %q without secrets", credentials, credential.Name)
+			}
+
+			if _, err := storage.GetById(ctx, credential.Id); err == nil {
+				t.Errorf("GetById() succeeded without the master key of the credential")
+			}
+		})
+	}
+}
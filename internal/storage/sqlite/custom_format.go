@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const customFormatColumns = "id, name, pattern, timestamp_field, timestamp_layout, level_field, levels, message_field, created_at, updated_at"
+
+// A CustomFormatStorage contains methods for communication with CustomFormat entity,
+// Levels of the CustomFormat are stored as json object
+type CustomFormatStorage struct {
+	connStr string
+}
+
+func NewCustomFormatStorage(connStr string) *CustomFormatStorage {
+	return &CustomFormatStorage{
+		connStr: connStr,
+	}
+}
+
+// A Create method creates new CustomFormat in database
+func (s *CustomFormatStorage) Create(ctx context.Context, format *entity.CustomFormat) error {
+	levels, err := json.Marshal(format.Levels)
+
+	if err != nil {
+		return fmt.Errorf("can not encode levels: %w", err)
+	}
+
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		"INSERT INTO custom_formats (name, pattern, timestamp_field, timestamp_layout, level_field, levels, message_field, created_at, updated_at) VALUES(?,?,?,?,?,?,?,?,?)",
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		format.Name,
+		format.Pattern,
+		format.TimestampField,
+		format.TimestampLayout,
+		format.LevelField,
+		string(levels),
+		format.MessageField,
+		format.CreatedAt,
+		format.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during executing query: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return fmt.Errorf("can not fetch last insert id: %w", err)
+	}
+
+	format.Id = int(id)
+
+	return nil
+}
+
+// A GetById method return CustomFormat if no errors
+// In case when CustomFormat is not found the method will return nil
+func (s *CustomFormatStorage) GetById(ctx context.Context, id int) (*entity.CustomFormat, error) {
+	formats, err := s.query(ctx, "SELECT "+customFormatColumns+" FROM custom_formats WHERE id = ?;", id)
+
+	if err != nil || len(formats) == 0 {
+		return nil, err
+	}
+
+	return &formats[0], nil
+}
+
+// A GetList method returns page of CustomFormats
+func (s *CustomFormatStorage) GetList(ctx context.Context, page, limit int) ([]entity.CustomFormat, error) {
+	if limit < 0 || page < 1 {
+		return nil, fmt.Errorf("invalid input parameters")
+	}
+
+	return s.query(ctx, "SELECT "+customFormatColumns+" FROM custom_formats ORDER BY id DESC LIMIT ? OFFSET ?;", limit, (page-1)*limit)
+}
+
+// An Update method updates CustomFormat by id
+func (s *CustomFormatStorage) Update(ctx context.Context, format *entity.CustomFormat) error {
+	levels, err := json.Marshal(format.Levels)
+
+	if err != nil {
+		return fmt.Errorf("can not encode levels: %w", err)
+	}
+
+	return s.exec(
+		ctx,
+		"UPDATE custom_formats SET name = ?, pattern = ?, timestamp_field = ?, timestamp_layout = ?, level_field = ?, levels = ?, message_field = ?, updated_at = ? WHERE id = ?;",
+		format.Name,
+		format.Pattern,
+		format.TimestampField,
+		format.TimestampLayout,
+		format.LevelField,
+		string(levels),
+		format.MessageField,
+		format.UpdatedAt,
+		format.Id,
+	)
+}
+
+// A DeleteById method deletes CustomFormat by id
+func (s *CustomFormatStorage) DeleteById(ctx context.Context, id int) error {
+	return s.exec(ctx, "DELETE FROM custom_formats WHERE id = ?;", id)
+}
+
+func (s *CustomFormatStorage) query(ctx context.Context, query string, args ...any) ([]entity.CustomFormat, error) {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+
+	defer rows.Close()
+
+	var formats []entity.CustomFormat
+
+	for rows.Next() {
+		var (
+			format entity.CustomFormat
+			levels string
+		)
+
+		err := rows.Scan(
+			&format.Id,
+			&format.Name,
+			&format.Pattern,
+			&format.TimestampField,
+			&format.TimestampLayout,
+			&format.LevelField,
+			&levels,
+			&format.MessageField,
+			&format.CreatedAt,
+			&format.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("can not scan custom format: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(levels), &format.Levels); err != nil {
+			return nil, fmt.Errorf("can not decode levels of custom format %d: %w", format.Id, err)
+		}
+
+		formats = append(formats, format)
+	}
+
+	return formats, rows.Err()
+}
+
+func (s *CustomFormatStorage) exec(ctx context.Context, query string, args ...any) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		return fmt.Errorf("query execution failed: %w", err)
+	}
+
+	return nil
+}
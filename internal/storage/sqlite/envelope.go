@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const masterKeySize = 32
+
+// ErrMasterKeyMissing is returned when secrets must be encrypted or decrypted, but master key is not configured
+var ErrMasterKeyMissing = errors.New("master key is not configured")
+
+// An EnvelopeCipher implements envelope encryption: every row is encrypted by own random data key
+// and the data key is stored next to the row encrypted by the master key
+type EnvelopeCipher struct {
+	master cipher.AEAD
+}
+
+// NewEnvelopeCipher constructs EnvelopeCipher from base64 encoded 32 bytes master key,
+// in case of empty master key the method returns nil, so secrets can not be stored
+func NewEnvelopeCipher(masterKey string) (*EnvelopeCipher, error) {
+	if masterKey == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("master key is not base64 encoded: %w", err)
+	}
+
+	if len(key) != masterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes long, got %d", masterKeySize, len(key))
+	}
+
+	aead, err := newAEAD(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnvelopeCipher{master: aead}, nil
+}
+
+// GenerateMasterKey returns new random master key encoded in base64
+func GenerateMasterKey() (string, error) {
+	key := make([]byte, masterKeySize)
+
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("can not generate master key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// NewDataKey method generates new data key and returns it in plain and wrapped by master key forms
+func (e *EnvelopeCipher) NewDataKey() ([]byte, string, error) {
+	dataKey := make([]byte, masterKeySize)
+
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", fmt.Errorf("can not generate data key: %w", err)
+	}
+
+	wrapped, err := e.WrapDataKey(dataKey)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dataKey, wrapped, nil
+}
+
+// WrapDataKey method encrypts data key by master key
+func (e *EnvelopeCipher) WrapDataKey(dataKey []byte) (string, error) {
+	return seal(e.master, dataKey, "data_key")
+}
+
+// UnwrapDataKey method decrypts data key wrapped by master key
+func (e *EnvelopeCipher) UnwrapDataKey(wrapped string) ([]byte, error) {
+	dataKey, err := open(e.master, wrapped, "data_key")
+
+	if err != nil {
+		return nil, fmt.Errorf("can not unwrap data key, probably master key is wrong: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+// Encrypt method encrypts the value by data key, the field is bound to ciphertext,
+// so encrypted values can not be swapped between columns
+func (e *EnvelopeCipher) Encrypt(dataKey []byte, field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	aead, err := newAEAD(dataKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	return seal(aead, []byte(value), field)
+}
+
+// Decrypt method decrypts the value encrypted by Encrypt method
+func (e *EnvelopeCipher) Decrypt(dataKey []byte, field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	aead, err := newAEAD(dataKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := open(aead, value, field)
+
+	if err != nil {
+		return "", fmt.Errorf("can not decrypt %s: %w", field, err)
+	}
+
+	return string(plain), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not create cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plain []byte, additional string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("can not generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plain, []byte(additional))
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func open(aead cipher.AEAD, value, additional string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, []byte(additional))
+}
@@ -0,0 +1,281 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestNewEnvelopeCipher(t *testing.T) {
+	generated, err := GenerateMasterKey()
+
+	if err != nil {
+		t.Fatalf("GenerateMasterKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		masterKey string
+		wantNil   bool
+		wantErr   bool
+	}{
+		{name: "generated key", masterKey: generated},
+		{name: "empty key disables encryption", masterKey: "", wantNil: true},
+		{name: "not base64", masterKey: "not a key!", wantErr: true},
+		{name: "short key", masterKey: base64.StdEncoding.EncodeToString(make([]byte, 16)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEnvelopeCipher(tt.masterKey)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewEnvelopeCipher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("NewEnvelopeCipher() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestEnvelopeCipherSealOpen(t *testing.T) {
+	e := mustEnvelopeCipher(t)
+	other := mustEnvelopeCipher(t)
+
+	dataKey, wrapped, err := e.NewDataKey()
+
+	if err != nil {
+		t.Fatalf("NewDataKey() error = %v", err)
+	}
+
+	encrypted, err := e.Encrypt(dataKey, "password", "s3cret")
+
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if encrypted == "s3cret" || strings.Contains(encrypted, "s3cret") {
+		t.Fatalf("Encrypt() = %q, value is not encrypted", encrypted)
+	}
+
+	_, otherKey, err := other.NewDataKey()
+
+	if err != nil {
+		t.Fatalf("NewDataKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cipher  *EnvelopeCipher
+		wrapped string
+		field   string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "same key and field", cipher: e, wrapped: wrapped, field: "password", value: encrypted, want: "s3cret"},
+		{name: "empty value", cipher: e, wrapped: wrapped, field: "password", value: "", want: ""},
+		{name: "another field", cipher: e, wrapped: wrapped, field: "passphrase", value: encrypted, wantErr: true},
+		{name: "another data key", cipher: other, wrapped: otherKey, field: "password", value: encrypted, wantErr: true},
+		{name: "another master key", cipher: other, wrapped: wrapped, field: "password", value: encrypted, wantErr: true},
+		{name: "corrupted value", cipher: e, wrapped: wrapped, field: "password", value: encrypted[:8], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.cipher.UnwrapDataKey(tt.wrapped)
+
+			if err == nil {
+				var got string
+
+				if got, err = tt.cipher.Decrypt(key, tt.field, tt.value); err == nil && got != tt.want {
+					t.Errorf("Decrypt() = %q, want %q", got, tt.want)
+				}
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCredentialStorageRotateMasterKey(t *testing.T) {
+	ctx := context.Background()
+	connStr := migratedDatabase(t)
+	current, next := mustEnvelopeCipher(t), mustEnvelopeCipher(t)
+
+	storage := NewCredentialStorage(connStr, current)
+
+	credentials := []*entity.Credential{
+		{Name: "encrypted", Kind: entity.CredentialKindInlineKey, PrivateKey: "key", Passphrase: "phrase"},
+		{Name: "without secrets", Kind: entity.CredentialKindAgent, AgentSocket: "/run/agent.sock"},
+	}
+
+	for _, credential := range credentials {
+		credential.CreatedAt, credential.UpdatedAt = "2026-10-18", "2026-10-18"
+
+		if err := storage.Create(ctx, credential); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// rows stored before encryption was enabled keep secrets in plain text without data key
+	legacy := &entity.Credential{Name: "stored before encryption", Kind: entity.CredentialKindPassword, Password: "plain"}
+	legacy.Id = insertPlainCredential(t, connStr, legacy)
+	credentials = append(credentials, legacy)
+
+	if _, err := storage.RotateMasterKey(ctx, nil); !errors.Is(err, ErrMasterKeyMissing) {
+		t.Errorf("RotateMasterKey(nil) error = %v, want %v", err, ErrMasterKeyMissing)
+	}
+
+	rotated, err := storage.RotateMasterKey(ctx, next)
+
+	if err != nil {
+		t.Fatalf("RotateMasterKey() error = %v", err)
+	}
+
+	if rotated != 2 {
+		t.Errorf("RotateMasterKey() = %d, want 2", rotated)
+	}
+
+	if _, err := storage.GetById(ctx, credentials[0].Id); err == nil {
+		t.Errorf("GetById() with previous master key succeeded")
+	}
+
+	if password := storedPassword(t, connStr, legacy.Id); password == legacy.Password {
+		t.Errorf("password of credential %d is stored in plain text after rotation", legacy.Id)
+	}
+
+	rotatedStorage := NewCredentialStorage(connStr, next)
+
+	for _, want := range credentials {
+		got, err := rotatedStorage.GetById(ctx, want.Id)
+
+		if err != nil {
+			t.Fatalf("GetById(%d) error = %v", want.Id, err)
+		}
+
+		if got.Password != want.Password || got.PrivateKey != want.PrivateKey || got.Passphrase != want.Passphrase {
+			t.Errorf("GetById(%d) = %+v, want %+v", want.Id, got, want)
+		}
+	}
+}
+
+func mustEnvelopeCipher(t *testing.T) *EnvelopeCipher {
+	t.Helper()
+
+	key, err := GenerateMasterKey()
+
+	if err != nil {
+		t.Fatalf("GenerateMasterKey() error = %v", err)
+	}
+
+	e, err := NewEnvelopeCipher(key)
+
+	if err != nil {
+		t.Fatalf("NewEnvelopeCipher() error = %v", err)
+	}
+
+	return e
+}
+
+func insertPlainCredential(t *testing.T, connStr string, credential *entity.Credential) int {
+	t.Helper()
+
+	db, err := sql.Open(DriverName, connStr)
+
+	if err != nil {
+		t.Fatalf("can not open sqlite connection: %v", err)
+	}
+
+	defer db.Close()
+
+	result, err := db.Exec(
+		"INSERT INTO credentials (name, kind, path, password, created_at, updated_at) VALUES(?,?,?,?,?,?)",
+		credential.Name,
+		credential.Kind,
+		"",
+		credential.Password,
+		"2026-10-18",
+		"2026-10-18",
+	)
+
+	if err != nil {
+		t.Fatalf("can not insert credential: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		t.Fatalf("can not get credential id: %v", err)
+	}
+
+	return int(id)
+}
+
+func storedPassword(t *testing.T, connStr string, id int) string {
+	t.Helper()
+
+	db, err := sql.Open(DriverName, connStr)
+
+	if err != nil {
+		t.Fatalf("can not open sqlite connection: %v", err)
+	}
+
+	defer db.Close()
+
+	var password string
+
+	if err := db.QueryRow("SELECT password FROM credentials WHERE id = ?", id).Scan(&password); err != nil {
+		t.Fatalf("can not read credential: %v", err)
+	}
+
+	return password
+}
+
+// migratedDatabase creates temporary database with all up migrations of the repository applied
+func migratedDatabase(t *testing.T) string {
+	t.Helper()
+
+	connStr := filepath.Join(t.TempDir(), "logman.db")
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+
+	if err != nil || len(files) == 0 {
+		t.Fatalf("can not find migrations: %v", err)
+	}
+
+	sort.Strings(files)
+
+	db, err := sql.Open(DriverName, connStr)
+
+	if err != nil {
+		t.Fatalf("can not open sqlite connection: %v", err)
+	}
+
+	defer db.Close()
+
+	for _, file := range files {
+		query, err := os.ReadFile(file)
+
+		if err != nil {
+			t.Fatalf("can not read migration: %v", err)
+		}
+
+		if _, err := db.Exec(string(query)); err != nil {
+			t.Fatalf("migration %s failed: %v", filepath.Base(file), err)
+		}
+	}
+
+	return connStr
+}
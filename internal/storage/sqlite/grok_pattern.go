@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const grokPatternColumns = "id, name, pattern, created_at, updated_at"
+
+// A GrokPatternStorage contains methods for communication with GrokPattern entity
+type GrokPatternStorage struct {
+	connStr string
+}
+
+func NewGrokPatternStorage(connStr string) *GrokPatternStorage {
+	return &GrokPatternStorage{
+		connStr: connStr,
+	}
+}
+
+// A Create method creates new GrokPattern in database
+func (s *GrokPatternStorage) Create(ctx context.Context, pattern *entity.GrokPattern) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	result, err := db.ExecContext(
+		ctx,
+		"INSERT INTO grok_patterns (name, pattern, created_at, updated_at) VALUES(?,?,?,?)",
+		pattern.Name,
+		pattern.Pattern,
+		pattern.CreatedAt,
+		pattern.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during executing query: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return fmt.Errorf("can not fetch last insert id: %w", err)
+	}
+
+	pattern.Id = int(id)
+
+	return nil
+}
+
+// A GetById method return GrokPattern if no errors
+// In case when GrokPattern is not found the method will return nil
+func (s *GrokPatternStorage) GetById(ctx context.Context, id int) (*entity.GrokPattern, error) {
+	patterns, err := s.query(ctx, "SELECT "+grokPatternColumns+" FROM grok_patterns WHERE id = ?;", id)
+
+	if err != nil || len(patterns) == 0 {
+		return nil, err
+	}
+
+	return &patterns[0], nil
+}
+
+// A GetList method returns all GrokPatterns ordered by name
+func (s *GrokPatternStorage) GetList(ctx context.Context) ([]entity.GrokPattern, error) {
+	return s.query(ctx, "SELECT "+grokPatternColumns+" FROM grok_patterns ORDER BY name;")
+}
+
+// An Update method updates GrokPattern by id
+func (s *GrokPatternStorage) Update(ctx context.Context, pattern *entity.GrokPattern) error {
+	return s.exec(
+		ctx,
+		"UPDATE grok_patterns SET name = ?, pattern = ?, updated_at = ? WHERE id = ?;",
+		pattern.Name,
+		pattern.Pattern,
+		pattern.UpdatedAt,
+		pattern.Id,
+	)
+}
+
+// A DeleteById method deletes GrokPattern by id
+func (s *GrokPatternStorage) DeleteById(ctx context.Context, id int) error {
+	return s.exec(ctx, "DELETE FROM grok_patterns WHERE id = ?;", id)
+}
+
+// An Import method creates GrokPatterns and replaces existing patterns of the same name in one transaction
+func (s *GrokPatternStorage) Import(ctx context.Context, patterns []entity.GrokPattern) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("can not begin transaction: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(
+		ctx,
+		"INSERT INTO grok_patterns (name, pattern, created_at, updated_at) VALUES(?,?,?,?) ON CONFLICT(name) DO UPDATE SET pattern = excluded.pattern, updated_at = excluded.updated_at;",
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	for _, pattern := range patterns {
+		if _, err := stmt.ExecContext(ctx, pattern.Name, pattern.Pattern, pattern.CreatedAt, pattern.UpdatedAt); err != nil {
+			return fmt.Errorf("error during importing pattern %s: %w", pattern.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *GrokPatternStorage) query(ctx context.Context, query string, args ...any) ([]entity.GrokPattern, error) {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+
+	defer rows.Close()
+
+	var patterns []entity.GrokPattern
+
+	for rows.Next() {
+		var pattern entity.GrokPattern
+
+		if err := rows.Scan(&pattern.Id, &pattern.Name, &pattern.Pattern, &pattern.CreatedAt, &pattern.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("can not scan grok pattern: %w", err)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, rows.Err()
+}
+
+func (s *GrokPatternStorage) exec(ctx context.Context, query string, args ...any) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("query execution failed: %w", err)
+	}
+
+	return nil
+}
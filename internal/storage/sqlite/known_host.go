@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+// A KnownHostStorage contains methods for communication with KnownHost entity
+type KnownHostStorage struct {
+	connStr string
+}
+
+func NewKnownHostStorage(connStr string) *KnownHostStorage {
+	return &KnownHostStorage{
+		connStr: connStr,
+	}
+}
+
+// A Save method creates KnownHost of the server or replaces the existing one
+func (k *KnownHostStorage) Save(ctx context.Context, knownHost *entity.KnownHost) error {
+	db, err := sql.Open(DriverName, k.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		`INSERT INTO known_hosts (server_id, host, key_type, fingerprint, public_key, pending_key_type, pending_fingerprint, pending_public_key, created_at, updated_at)
+		VALUES(?,?,?,?,?,?,?,?,?,?)
+		ON CONFLICT(server_id) DO UPDATE SET
+			host = excluded.host,
+			key_type = excluded.key_type,
+			fingerprint = excluded.fingerprint,
+			public_key = excluded.public_key,
+			pending_key_type = excluded.pending_key_type,
+			pending_fingerprint = excluded.pending_fingerprint,
+			pending_public_key = excluded.pending_public_key,
+			updated_at = excluded.updated_at;`,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		knownHost.ServerId,
+		knownHost.Host,
+		knownHost.KeyType,
+		knownHost.Fingerprint,
+		knownHost.PublicKey,
+		knownHost.PendingKeyType,
+		knownHost.PendingFingerprint,
+		knownHost.PendingPublicKey,
+		knownHost.CreatedAt,
+		knownHost.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during executing query: %w", err)
+	}
+
+	return nil
+}
+
+// A GetByServerId method returns KnownHost pinned for the server,
+// in case when the key is not pinned yet the method will return nil
+func (k *KnownHostStorage) GetByServerId(ctx context.Context, serverId int) (*entity.KnownHost, error) {
+	db, err := sql.Open(DriverName, k.connStr)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		"SELECT id, server_id, host, key_type, fingerprint, public_key, pending_key_type, pending_fingerprint, pending_public_key, created_at, updated_at FROM known_hosts WHERE server_id = ?;",
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	var knownHost entity.KnownHost
+
+	err = stmt.QueryRowContext(ctx, serverId).Scan(
+		&knownHost.Id,
+		&knownHost.ServerId,
+		&knownHost.Host,
+		&knownHost.KeyType,
+		&knownHost.Fingerprint,
+		&knownHost.PublicKey,
+		&knownHost.PendingKeyType,
+		&knownHost.PendingFingerprint,
+		&knownHost.PendingPublicKey,
+		&knownHost.CreatedAt,
+		&knownHost.UpdatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error during scanning row: %w", err)
+	}
+
+	return &knownHost, nil
+}
+
+// A DeleteByServerId method removes pinned key of the server
+func (k *KnownHostStorage) DeleteByServerId(ctx context.Context, serverId int) error {
+	db, err := sql.Open(DriverName, k.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		"DELETE FROM known_hosts WHERE server_id = ?;",
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, serverId); err != nil {
+		return fmt.Errorf("query execution failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+const logLocationColumns = "id, server_id, name, kind, path, format, pattern, unit, priority, container_name, container_label, created_at, updated_at"
+
+// A LogLocationStorage contains methods for communication with LogLocation entity
+type LogLocationStorage struct {
+	connStr string
+}
+
+func NewLogLocationStorage(connStr string) *LogLocationStorage {
+	return &LogLocationStorage{
+		connStr: connStr,
+	}
+}
+
+// A Create method creates new LogLocation in database
+func (s *LogLocationStorage) Create(ctx context.Context, location *entity.LogLocation) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		"INSERT INTO log_locations (server_id, name, kind, path, format, pattern, unit, priority, container_name, container_label, created_at, updated_at) VALUES(?,?,?,?,?,?,?,?,?,?,?,?)",
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(
+		ctx,
+		location.ServerId,
+		location.Name,
+		location.Kind,
+		location.Path,
+		location.Format,
+		location.Pattern,
+		location.Unit,
+		location.Priority,
+		location.ContainerName,
+		location.ContainerLabel,
+		location.CreatedAt,
+		location.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during executing query: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return fmt.Errorf("can not fetch last insert id: %w", err)
+	}
+
+	location.Id = int(id)
+
+	return nil
+}
+
+// A GetById method return LogLocation if no errors
+// In case when LogLocation is not found the method will return nil
+func (s *LogLocationStorage) GetById(ctx context.Context, id int) (*entity.LogLocation, error) {
+	locations, err := s.query(ctx, "SELECT "+logLocationColumns+" FROM log_locations WHERE id = ?;", id)
+
+	if err != nil || len(locations) == 0 {
+		return nil, err
+	}
+
+	return &locations[0], nil
+}
+
+// A GetListByServerId method returns all LogLocations of the Server
+func (s *LogLocationStorage) GetListByServerId(ctx context.Context, serverId int) ([]entity.LogLocation, error) {
+	return s.query(ctx, "SELECT "+logLocationColumns+" FROM log_locations WHERE server_id = ? ORDER BY id;", serverId)
+}
+
+// A GetListByFormat method returns all LogLocations of the format
+func (s *LogLocationStorage) GetListByFormat(ctx context.Context, format entity.LogFormat) ([]entity.LogLocation, error) {
+	return s.query(ctx, "SELECT "+logLocationColumns+" FROM log_locations WHERE format = ? ORDER BY id;", format)
+}
+
+// An Update method updates LogLocation by id
+func (s *LogLocationStorage) Update(ctx context.Context, location *entity.LogLocation, id int) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		"UPDATE log_locations SET name = ?, kind = ?, path = ?, format = ?, pattern = ?, unit = ?, priority = ?, container_name = ?, container_label = ?, updated_at = ? WHERE id = ?;",
+	)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		location.Name,
+		location.Kind,
+		location.Path,
+		location.Format,
+		location.Pattern,
+		location.Unit,
+		location.Priority,
+		location.ContainerName,
+		location.ContainerLabel,
+		location.UpdatedAt,
+		id,
+	)
+
+	if err != nil {
+		return fmt.Errorf("query execution failed: %w", err)
+	}
+
+	return nil
+}
+
+// A DeleteById method deletes LogLocation by id
+func (s *LogLocationStorage) DeleteById(ctx context.Context, id int) error {
+	return s.exec(ctx, "DELETE FROM log_locations WHERE id = ?;", id)
+}
+
+func (s *LogLocationStorage) query(ctx context.Context, query string, args ...any) ([]entity.LogLocation, error) {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return nil, fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("query execution failed: %w", err)
+	}
+
+	defer rows.Close()
+
+	var locations []entity.LogLocation
+
+	for rows.Next() {
+		var location entity.LogLocation
+
+		err := rows.Scan(
+			&location.Id,
+			&location.ServerId,
+			&location.Name,
+			&location.Kind,
+			&location.Path,
+			&location.Format,
+			&location.Pattern,
+			&location.Unit,
+			&location.Priority,
+			&location.ContainerName,
+			&location.ContainerLabel,
+			&location.CreatedAt,
+			&location.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("can not scan log location: %w", err)
+		}
+
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+func (s *LogLocationStorage) exec(ctx context.Context, query string, args ...any) error {
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(ctx, query)
+
+	if err != nil {
+		return fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		return fmt.Errorf("query execution failed: %w", err)
+	}
+
+	return nil
+}
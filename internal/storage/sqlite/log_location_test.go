@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krasilnikovm/logman/internal/entity"
+)
+
+func TestServerStorageDeleteByIdCascadesLogLocations(t *testing.T) {
+	ctx := context.Background()
+	connStr := ConnectionString(migratedDatabase(t))
+
+	servers := NewServerStorage(connStr)
+	locations := NewLogLocationStorage(connStr)
+
+	tests := []struct {
+		name      string
+		deleted   bool
+		wantCount int
+	}{
+		{name: "server is kept", deleted: false, wantCount: 1},
+		{name: "server is deleted", deleted: true, wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &entity.Server{
+				Name:      tt.name,
+				Kind:      entity.ServerKindLocal,
+				CreatedAt: "2026-10-18",
+				UpdatedAt: "2026-10-18",
+			}
+
+			if err := servers.Create(ctx, server); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			location := &entity.LogLocation{
+				ServerId:  server.Id,
+				Name:      "syslog",
+				Kind:      entity.LogLocationKindFile,
+				Path:      "/var/log/syslog",
+				Format:    entity.LogLocationFormatSyslogRfc3164,
+				CreatedAt: "2026-10-18",
+				UpdatedAt: "2026-10-18",
+			}
+
+			if err := locations.Create(ctx, location); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			if tt.deleted {
+				if err := servers.DeleteById(ctx, server.Id); err != nil {
+					t.Fatalf("DeleteById() error = %v", err)
+				}
+			}
+
+			got, err := locations.GetListByServerId(ctx, server.Id)
+
+			if err != nil {
+				t.Fatalf("GetListByServerId() error = %v", err)
+			}
+
+			if len(got) != tt.wantCount {
+				t.Errorf("GetListByServerId() = %d locations, want %d", len(got), tt.wantCount)
+			}
+		})
+	}
+}
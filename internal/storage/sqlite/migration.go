@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// RunMigrations up the migrations of migrations directory on the database file, the api server and cli commands
+// run them before the database is used
+func RunMigrations(path string) error {
+	db, err := sql.Open(DriverName, path)
+
+	if err != nil {
+		return fmt.Errorf("can not open connection: %w", err)
+	}
+
+	defer db.Close()
+
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+
+	if err != nil {
+		return fmt.Errorf("can not create driver: %w", err)
+	}
+
+	defer driver.Close()
+
+	mgr, err := migrate.NewWithDatabaseInstance(
+		"file://migrations",
+		DriverName,
+		driver,
+	)
+
+	if err != nil {
+		return fmt.Errorf("can not create migrate instance: %w", err)
+	}
+
+	defer mgr.Close()
+
+	if err = mgr.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("execute migration failed: %w", err)
+	}
+
+	return nil
+}
@@ -11,6 +11,11 @@ import (
 
 const DriverName = "sqlite3"
 
+// ConnectionString returns connection string of the database file which enforces foreign keys
+func ConnectionString(path string) string {
+	return path + "?_foreign_keys=on"
+}
+
 // A ServerStorage contains methods for communication with Server entity
 type ServerStorage struct {
 	connStr string
@@ -34,7 +39,7 @@ func (s *ServerStorage) Create(ctx context.Context, server *entity.Server) error
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"INSERT INTO servers (name, host, log_location_path, log_location_format, credential_id, created_at, updated_at) VALUES(?,?,?,?,?,?,?)",
+		"INSERT INTO servers (name, kind, host, port, username, connect_timeout, keep_alive_interval, keep_alive_count_max, use_sudo, transport, credential_id, jump_server_id, created_at, updated_at) VALUES(?,?,?,?,?,?,?,?,?,?,NULLIF(?, 0),?,?,?)",
 	)
 
 	if err != nil {
@@ -46,10 +51,17 @@ func (s *ServerStorage) Create(ctx context.Context, server *entity.Server) error
 	result, err := stmt.ExecContext(
 		ctx,
 		server.Name,
+		server.Kind,
 		server.Host,
-		server.LogFolderPath,
-		server.LogFormat,
+		server.Port,
+		server.Username,
+		server.ConnectTimeout,
+		server.KeepAliveInterval,
+		server.KeepAliveCountMax,
+		server.UseSudo,
+		server.Transport,
 		server.CredentialId,
+		server.JumpServerId,
 		server.CreatedAt,
 		server.UpdatedAt,
 	)
@@ -82,7 +94,7 @@ func (s *ServerStorage) GetById(ctx context.Context, id int) (*entity.Server, er
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"SELECT id, name, host, log_location_path, log_location_format, credential_id, created_at, updated_at FROM servers WHERE id = ?;",
+		"SELECT id, name, kind, host, port, username, connect_timeout, keep_alive_interval, keep_alive_count_max, use_sudo, transport, COALESCE(credential_id, 0), jump_server_id, created_at, updated_at FROM servers WHERE id = ?;",
 	)
 
 	if err != nil {
@@ -108,10 +120,17 @@ func (s *ServerStorage) GetById(ctx context.Context, id int) (*entity.Server, er
 	rows.Scan(
 		&server.Id,
 		&server.Name,
+		&server.Kind,
 		&server.Host,
-		&server.LogFolderPath,
-		&server.LogFormat,
+		&server.Port,
+		&server.Username,
+		&server.ConnectTimeout,
+		&server.KeepAliveInterval,
+		&server.KeepAliveCountMax,
+		&server.UseSudo,
+		&server.Transport,
 		&server.CredentialId,
+		&server.JumpServerId,
 		&server.CreatedAt,
 		&server.UpdatedAt,
 	)
@@ -165,7 +184,7 @@ func (s *ServerStorage) GetList(ctx context.Context, limit, page int) ([]entity.
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"SELECT id, name, host, log_location_path, log_location_format, credential_id, created_at, updated_at FROM servers ORDER BY id DESC LIMIT ? OFFSET ?;",
+		"SELECT id, name, kind, host, port, username, connect_timeout, keep_alive_interval, keep_alive_count_max, use_sudo, transport, COALESCE(credential_id, 0), jump_server_id, created_at, updated_at FROM servers ORDER BY id DESC LIMIT ? OFFSET ?;",
 	)
 
 	if err != nil {
@@ -188,10 +207,17 @@ func (s *ServerStorage) GetList(ctx context.Context, limit, page int) ([]entity.
 		rows.Scan(
 			&server.Id,
 			&server.Name,
+			&server.Kind,
 			&server.Host,
-			&server.LogFolderPath,
-			&server.LogFormat,
+			&server.Port,
+			&server.Username,
+			&server.ConnectTimeout,
+			&server.KeepAliveInterval,
+			&server.KeepAliveCountMax,
+			&server.UseSudo,
+			&server.Transport,
 			&server.CredentialId,
+			&server.JumpServerId,
 			&server.CreatedAt,
 			&server.UpdatedAt,
 		)
@@ -202,6 +228,78 @@ func (s *ServerStorage) GetList(ctx context.Context, limit, page int) ([]entity.
 	return servers, nil
 }
 
+// A GetListByCredentialId method returns Servers which connect by the Credential
+func (s *ServerStorage) GetListByCredentialId(ctx context.Context, credentialId int) ([]entity.Server, error) {
+	return s.getListBy(ctx, "credential_id", credentialId)
+}
+
+// A GetListByJumpServerId method returns Servers which are reached through the jump Server
+func (s *ServerStorage) GetListByJumpServerId(ctx context.Context, jumpServerId int) ([]entity.Server, error) {
+	return s.getListBy(ctx, "jump_server_id", jumpServerId)
+}
+
+// getListBy method returns Servers with the value of the column ordered by id
+func (s *ServerStorage) getListBy(ctx context.Context, column string, value int) ([]entity.Server, error) {
+	var servers []entity.Server
+
+	db, err := sql.Open(DriverName, s.connStr)
+
+	if err != nil {
+		return servers, fmt.Errorf("can not open sqlite connection: %w", err)
+	}
+
+	defer db.Close()
+
+	stmt, err := db.PrepareContext(
+		ctx,
+		"SELECT id, name, kind, host, port, username, connect_timeout, keep_alive_interval, keep_alive_count_max, use_sudo, transport, COALESCE(credential_id, 0), jump_server_id, created_at, updated_at FROM servers WHERE "+column+" = ? ORDER BY id;",
+	)
+
+	if err != nil {
+		return servers, fmt.Errorf("error during preparing query: %w", err)
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, value)
+
+	if err != nil {
+		return servers, fmt.Errorf("query execution failed: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var server entity.Server
+
+		err := rows.Scan(
+			&server.Id,
+			&server.Name,
+			&server.Kind,
+			&server.Host,
+			&server.Port,
+			&server.Username,
+			&server.ConnectTimeout,
+			&server.KeepAliveInterval,
+			&server.KeepAliveCountMax,
+			&server.UseSudo,
+			&server.Transport,
+			&server.CredentialId,
+			&server.JumpServerId,
+			&server.CreatedAt,
+			&server.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("can not scan server: %w", err)
+		}
+
+		servers = append(servers, server)
+	}
+
+	return servers, rows.Err()
+}
+
 // A Update method updates Server by id
 func (s *ServerStorage) Update(ctx context.Context, server *entity.Server, id int) error {
 	db, err := sql.Open(DriverName, s.connStr)
@@ -214,7 +312,7 @@ func (s *ServerStorage) Update(ctx context.Context, server *entity.Server, id in
 
 	stmt, err := db.PrepareContext(
 		ctx,
-		"UPDATE servers SET name = ?, host = ?, log_location_path = ?, log_location_format = ?, credential_id = ?, updated_at = ? WHERE id = ?;",
+		"UPDATE servers SET name = ?, kind = ?, host = ?, port = ?, username = ?, connect_timeout = ?, keep_alive_interval = ?, keep_alive_count_max = ?, use_sudo = ?, transport = ?, credential_id = NULLIF(?, 0), jump_server_id = ?, updated_at = ? WHERE id = ?;",
 	)
 
 	if err != nil {
@@ -226,10 +324,17 @@ func (s *ServerStorage) Update(ctx context.Context, server *entity.Server, id in
 	_, err = stmt.ExecContext(
 		ctx,
 		server.Name,
+		server.Kind,
 		server.Host,
-		server.LogFolderPath,
-		server.LogFormat,
+		server.Port,
+		server.Username,
+		server.ConnectTimeout,
+		server.KeepAliveInterval,
+		server.KeepAliveCountMax,
+		server.UseSudo,
+		server.Transport,
 		server.CredentialId,
+		server.JumpServerId,
 		server.UpdatedAt,
 		id,
 	)
@@ -0,0 +1,14 @@
+CREATE TABLE known_hosts (
+    `id` INTEGER PRIMARY KEY,
+    `server_id` INTEGER NOT NULL UNIQUE,
+    `host` TEXT NOT NULL,
+    `key_type` TEXT NOT NULL,
+    `fingerprint` TEXT NOT NULL,
+    `public_key` TEXT NOT NULL,
+    `pending_key_type` TEXT NOT NULL DEFAULT '',
+    `pending_fingerprint` TEXT NOT NULL DEFAULT '',
+    `pending_public_key` TEXT NOT NULL DEFAULT '',
+    `created_at` TEXT NOT NULL,
+    `updated_at` TEXT NOT NULL,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+ALTER TABLE credentials ADD COLUMN `kind` TEXT NOT NULL DEFAULT 'key';
+ALTER TABLE credentials ADD COLUMN `passphrase` TEXT NOT NULL DEFAULT '';
+ALTER TABLE credentials ADD COLUMN `password` TEXT NOT NULL DEFAULT '';
+ALTER TABLE credentials ADD COLUMN `agent_socket` TEXT NOT NULL DEFAULT '';
+ALTER TABLE credentials ADD COLUMN `private_key` TEXT NOT NULL DEFAULT '';
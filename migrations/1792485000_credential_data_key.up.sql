@@ -0,0 +1 @@
+ALTER TABLE credentials ADD COLUMN `data_key` TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN `jump_server_id` INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE servers ADD COLUMN `port` INTEGER NOT NULL DEFAULT 22;
+ALTER TABLE servers ADD COLUMN `username` TEXT NOT NULL DEFAULT '';
+ALTER TABLE servers ADD COLUMN `connect_timeout` INTEGER NOT NULL DEFAULT 10;
+ALTER TABLE servers ADD COLUMN `keep_alive_interval` INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE servers ADD COLUMN `keep_alive_count_max` INTEGER NOT NULL DEFAULT 3;
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN `use_sudo` INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN `transport` TEXT NOT NULL DEFAULT 'auto';
@@ -0,0 +1 @@
+ALTER TABLE servers ADD COLUMN `kind` TEXT NOT NULL DEFAULT 'ssh';
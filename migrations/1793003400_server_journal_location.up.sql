@@ -0,0 +1,3 @@
+ALTER TABLE servers ADD COLUMN `log_location_kind` TEXT NOT NULL DEFAULT 'file';
+ALTER TABLE servers ADD COLUMN `log_location_unit` TEXT NOT NULL DEFAULT '';
+ALTER TABLE servers ADD COLUMN `log_location_priority` TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE servers ADD COLUMN `log_location_container_name` TEXT NOT NULL DEFAULT '';
+ALTER TABLE servers ADD COLUMN `log_location_container_label` TEXT NOT NULL DEFAULT '';
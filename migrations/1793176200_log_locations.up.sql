@@ -0,0 +1,40 @@
+CREATE TABLE log_locations (
+    `id` INTEGER PRIMARY KEY,
+    `server_id` INTEGER NOT NULL,
+    `name` TEXT NOT NULL,
+    `kind` TEXT NOT NULL,
+    `path` TEXT NOT NULL DEFAULT '',
+    `format` TEXT NOT NULL,
+    `unit` TEXT NOT NULL DEFAULT '',
+    `priority` TEXT NOT NULL DEFAULT '',
+    `container_name` TEXT NOT NULL DEFAULT '',
+    `container_label` TEXT NOT NULL DEFAULT '',
+    `created_at` TEXT NOT NULL,
+    `updated_at` TEXT NOT NULL,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX log_locations_server_id ON log_locations (server_id);
+
+INSERT INTO log_locations (server_id, name, kind, path, format, unit, priority, container_name, container_label, created_at, updated_at)
+SELECT
+    id,
+    'default',
+    log_location_kind,
+    CASE WHEN log_location_kind = 'file' THEN rtrim(log_location_path, '/') || '/*' ELSE '' END,
+    log_location_format,
+    log_location_unit,
+    log_location_priority,
+    log_location_container_name,
+    log_location_container_label,
+    created_at,
+    updated_at
+FROM servers;
+
+ALTER TABLE servers DROP COLUMN `log_location_kind`;
+ALTER TABLE servers DROP COLUMN `log_location_path`;
+ALTER TABLE servers DROP COLUMN `log_location_format`;
+ALTER TABLE servers DROP COLUMN `log_location_unit`;
+ALTER TABLE servers DROP COLUMN `log_location_priority`;
+ALTER TABLE servers DROP COLUMN `log_location_container_name`;
+ALTER TABLE servers DROP COLUMN `log_location_container_label`;
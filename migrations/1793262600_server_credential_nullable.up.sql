@@ -0,0 +1,43 @@
+CREATE TABLE servers_new (
+    `id` INTEGER PRIMARY KEY,
+    `credential_id` INTEGER NULL,
+    `name` TEXT NOT NULL,
+    `host` TEXT NOT NULL,
+    `created_at` TEXT NOT NULL,
+    `updated_at` TEXT NOT NULL,
+    `jump_server_id` INTEGER NOT NULL DEFAULT 0,
+    `port` INTEGER NOT NULL DEFAULT 22,
+    `username` TEXT NOT NULL DEFAULT '',
+    `connect_timeout` INTEGER NOT NULL DEFAULT 10,
+    `keep_alive_interval` INTEGER NOT NULL DEFAULT 0,
+    `keep_alive_count_max` INTEGER NOT NULL DEFAULT 3,
+    `use_sudo` INTEGER NOT NULL DEFAULT 0,
+    `transport` TEXT NOT NULL DEFAULT 'auto',
+    `kind` TEXT NOT NULL DEFAULT 'ssh',
+    FOREIGN KEY (credential_id) REFERENCES credentials(id)
+);
+
+INSERT INTO servers_new (id, credential_id, name, host, created_at, updated_at, jump_server_id, port, username, connect_timeout, keep_alive_interval, keep_alive_count_max, use_sudo, transport, kind)
+SELECT
+    id,
+    CASE WHEN credential_id IN (SELECT id FROM credentials) THEN credential_id ELSE NULL END,
+    name,
+    host,
+    created_at,
+    updated_at,
+    jump_server_id,
+    port,
+    username,
+    connect_timeout,
+    keep_alive_interval,
+    keep_alive_count_max,
+    use_sudo,
+    transport,
+    kind
+FROM servers;
+
+DROP TABLE servers;
+
+ALTER TABLE servers_new RENAME TO servers;
+
+CREATE INDEX servers_credential_id ON servers (credential_id);
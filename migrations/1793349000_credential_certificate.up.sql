@@ -0,0 +1 @@
+ALTER TABLE credentials ADD COLUMN `certificate` TEXT NOT NULL DEFAULT '';
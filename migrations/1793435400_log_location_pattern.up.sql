@@ -0,0 +1 @@
+ALTER TABLE log_locations ADD COLUMN `pattern` TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+CREATE TABLE custom_formats (
+    `id` INTEGER PRIMARY KEY,
+    `name` TEXT NOT NULL,
+    `pattern` TEXT NOT NULL,
+    `timestamp_field` TEXT NOT NULL DEFAULT '',
+    `timestamp_layout` TEXT NOT NULL DEFAULT '',
+    `level_field` TEXT NOT NULL DEFAULT '',
+    `levels` TEXT NOT NULL DEFAULT '{}',
+    `message_field` TEXT NOT NULL DEFAULT '',
+    `created_at` TEXT NOT NULL,
+    `updated_at` TEXT NOT NULL
+);
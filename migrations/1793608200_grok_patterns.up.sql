@@ -0,0 +1,7 @@
+CREATE TABLE grok_patterns (
+    `id` INTEGER PRIMARY KEY,
+    `name` TEXT NOT NULL UNIQUE,
+    `pattern` TEXT NOT NULL,
+    `created_at` TEXT NOT NULL,
+    `updated_at` TEXT NOT NULL
+);